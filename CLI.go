@@ -23,21 +23,33 @@ func NewCLI(in io.Reader, out io.Writer, game Game) *CLI {
 	}
 }
 
-const PlayerPrompt = "Please enter the number of players: "
-const BadPlayerInputErrMsg = "Bad value received for number of players, please try again with a number"
+const PlayerPrompt = "Please enter the number of players, or their names separated by commas: "
+const BadPlayerInputErrMsg = "Bad value received for the players, please try again with a number or a list of names"
 const BadWinnerInputMsg = "Bad winner entry, please enter '<name> wins'"
+const RecordWinErrMsg = "Sorry, the win could not be recorded"
+const StartGameErrMsg = "Sorry, the game could not be started"
+const GameStartedMsg = "Game %d started, record buy-ins against it\n"
 
 func (cli *CLI) PlayPoker() {
 	fmt.Fprint(cli.out, PlayerPrompt)
 
-	numberOfPlayers, err := strconv.Atoi(cli.readLine())
+	numberOfPlayers, players, err := parsePlayers(cli.readLine())
 
 	if err != nil {
 		fmt.Fprint(cli.out, BadPlayerInputErrMsg)
 		return
 	}
 
-	cli.game.Start(numberOfPlayers, cli.out)
+	game, err := cli.game.Start(numberOfPlayers, players, cli.out)
+
+	if err != nil {
+		fmt.Fprintf(cli.out, "%s, %v", StartGameErrMsg, err)
+		return
+	}
+
+	if id := game.ID(); id != 0 {
+		fmt.Fprintf(cli.out, GameStartedMsg, id)
+	}
 
 	winnerInput := cli.readLine()
 	winner, err := extractWinner(winnerInput)
@@ -46,14 +58,48 @@ func (cli *CLI) PlayPoker() {
 		return
 	}
 
-	cli.game.Finish(winner)
+	if err := game.Finish(winner, CLISource); err != nil {
+		fmt.Fprintf(cli.out, "%s, %v", RecordWinErrMsg, err)
+	}
 }
 
 func extractWinner(userInput string) (string, error) {
-	if strings.Contains(userInput, " wins") {
-		return strings.Replace(userInput, " wins", "", 1), nil
+	fields := strings.Fields(userInput)
+	last := len(fields) - 1
+
+	if last < 1 || !strings.EqualFold(fields[last], "wins") {
+		return "", errors.New(BadWinnerInputMsg)
+	}
+
+	return strings.Join(fields[:last], " "), nil
+}
+
+// parsePlayers reads either the number of players or the names of at least
+// two players separated by commas.
+func parsePlayers(userInput string) (int, []string, error) {
+	if numberOfPlayers, err := strconv.Atoi(strings.TrimSpace(userInput)); err == nil {
+		return numberOfPlayers, nil, nil
+	}
+
+	var players []string
+	seen := map[string]bool{}
+
+	for _, name := range strings.Split(userInput, ",") {
+		name = NormalisePlayerName(name)
+
+		if name == "" || seen[playerKey(name)] {
+			return 0, nil, errors.New(BadPlayerInputErrMsg)
+		}
+
+		seen[playerKey(name)] = true
+		players = append(players, name)
 	}
-	return "", errors.New(BadWinnerInputMsg)
+
+	if len(players) < 2 {
+		return 0, nil, errors.New(BadPlayerInputErrMsg)
+	}
+
+	return len(players), players, nil
 }
 
 func (cli *CLI) readLine() string {
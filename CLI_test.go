@@ -2,6 +2,9 @@ package poker_test
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -26,6 +29,45 @@ func TestCLI(t *testing.T) {
 		assertMessageSentToUser(t, stdout, poker.PlayerPrompt)
 		assertGameStartedWith(t, game, 1)
 		assertFinishCalledWith(t, game, "Chris")
+		assertFinishedFrom(t, game, poker.SourceCLI)
+	})
+
+	t.Run("starts game with the names of the players", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		in := strings.NewReader("Chris, Cleo,Ruth\nCleo wins\n")
+		game := &poker.GameSpy{}
+
+		cli := poker.NewCLI(in, stdout, game)
+		cli.PlayPoker()
+
+		assertGameStartedWith(t, game, 3)
+
+		if got, want := game.StartedWithPlayerNames(), []string{"Chris", "Cleo", "Ruth"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got players %v want %v", got, want)
+		}
+	})
+
+	t.Run("it does not start game when a player is named twice", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		in := strings.NewReader("Chris, chris\n")
+		game := &poker.GameSpy{}
+
+		cli := poker.NewCLI(in, stdout, game)
+		cli.PlayPoker()
+
+		assertGameNotStarted(t, game)
+		assertMessageSentToUser(t, stdout, poker.PlayerPrompt, poker.BadPlayerInputErrMsg)
+	})
+
+	t.Run("it trims stray whitespace around the winner", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		in := strings.NewReader("1\n  Chris   Jones  wins \n")
+		game := &poker.GameSpy{}
+
+		cli := poker.NewCLI(in, stdout, game)
+		cli.PlayPoker()
+
+		assertFinishCalledWith(t, game, "Chris Jones")
 	})
 
 	t.Run("it does not start game when a non numeric value is entered", func(t *testing.T) {
@@ -40,6 +82,40 @@ func TestCLI(t *testing.T) {
 		assertMessageSentToUser(t, stdout, poker.PlayerPrompt, poker.BadPlayerInputErrMsg)
 	})
 
+	t.Run("it tells the user when the win could not be recorded", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		game := &poker.GameSpy{FinishError: errors.New("disk full")}
+		in := strings.NewReader("1\nChris wins\n")
+
+		cli := poker.NewCLI(in, stdout, game)
+		cli.PlayPoker()
+
+		assertMessageSentToUser(t, stdout, poker.PlayerPrompt, poker.RecordWinErrMsg+", disk full")
+	})
+
+	t.Run("it tells the user the id to record buy-ins against", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		game := &poker.GameSpy{StartedID: 7}
+		in := strings.NewReader("2\nChris wins\n")
+
+		cli := poker.NewCLI(in, stdout, game)
+		cli.PlayPoker()
+
+		assertMessageSentToUser(t, stdout, poker.PlayerPrompt, fmt.Sprintf(poker.GameStartedMsg, 7))
+	})
+
+	t.Run("it tells the user when the game could not be started", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		game := &poker.GameSpy{StartError: errors.New("disk full")}
+		in := strings.NewReader("2\nChris wins\n")
+
+		cli := poker.NewCLI(in, stdout, game)
+		cli.PlayPoker()
+
+		assertGameNotFinished(t, game)
+		assertMessageSentToUser(t, stdout, poker.PlayerPrompt, poker.StartGameErrMsg+", disk full")
+	})
+
 	t.Run("it does not finish game if winner winner entered incorrectly", func(t *testing.T) {
 		stdout := &bytes.Buffer{}
 		game := &poker.GameSpy{}
@@ -56,22 +132,30 @@ func TestCLI(t *testing.T) {
 func assertGameStartedWith(t *testing.T, game *poker.GameSpy, numberOfPlayerswanted int) {
 	t.Helper()
 	passed := retryUntil(500*time.Millisecond, func() bool {
-		return game.StartedWith == numberOfPlayerswanted
+		return game.StartedWithPlayers() == numberOfPlayerswanted
 	})
 
 	if !passed {
-		t.Errorf("expected game to be started with %d, but got %d", game.StartedWith, numberOfPlayerswanted)
+		t.Errorf("expected game to be started with %d, but got %d", numberOfPlayerswanted, game.StartedWithPlayers())
 	}
 }
 
 func assertFinishCalledWith(t *testing.T, game *poker.GameSpy, winner string) {
 	t.Helper()
 	passed := retryUntil(500*time.Millisecond, func() bool {
-		return game.FinishedWith == winner
+		return game.FinishedWithWinner() == winner
 	})
 
 	if !passed {
-		t.Errorf("expected game to be finished with %q, but got %q", game.FinishedWith, winner)
+		t.Errorf("expected game to be finished with %q, but got %q", winner, game.FinishedWithWinner())
+	}
+}
+
+func assertFinishedFrom(t *testing.T, game *poker.GameSpy, kind string) {
+	t.Helper()
+
+	if got := game.FinishedFromSource().Kind; got != kind {
+		t.Errorf("expected game to be finished from %q, but got %q", kind, got)
 	}
 }
 
@@ -0,0 +1,132 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrPlayerNotFound = errors.New("player not found")
+	ErrAliasConflict  = errors.New("alias conflicts with another player")
+)
+
+// AliasStore is implemented by player stores that let several names resolve
+// to one player. The PlayerStore methods of such a store follow aliases.
+type AliasStore interface {
+	AddAlias(player, alias string) error
+	GetAliases(player string) ([]string, error)
+	MergePlayers(from, into string) error
+	ResolvePlayer(name string) (string, error)
+}
+
+// AddAlias makes alias resolve to player. An alias can't be the name of a
+// player with wins of their own, those players should be merged instead.
+func (f *FileSystemPlayerStore) AddAlias(player, alias string) error {
+	return f.update(func(db *playerDB) error {
+		name := db.resolve(player)
+		key := playerKey(alias)
+
+		if key == "" || key == playerKey(name) {
+			return fmt.Errorf("%w: %q can't be an alias of %s", ErrAliasConflict, alias, name)
+		}
+
+		if db.hasPlayer(alias) {
+			return fmt.Errorf("%w: %s has wins of their own, merge them instead", ErrAliasConflict, NormalisePlayerName(alias))
+		}
+
+		if existing, ok := db.Aliases[key]; ok && playerKey(existing) != playerKey(name) {
+			return fmt.Errorf("%w: %s is already an alias of %s", ErrAliasConflict, NormalisePlayerName(alias), existing)
+		}
+
+		db.Aliases[key] = name
+
+		db.log(auditRecord{Action: addAliasAction, Player: name, To: NormalisePlayerName(alias)})
+		return nil
+	})
+}
+
+func (f *FileSystemPlayerStore) GetAliases(player string) ([]string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	key := playerKey(f.db.resolve(player))
+	aliases := []string{}
+
+	for alias, name := range f.db.Aliases {
+		if playerKey(name) == key {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	sort.Strings(aliases)
+	return aliases, nil
+}
+
+// ResolvePlayer returns the name of the player name is an alias of, or name
+// itself, normalised, when it isn't an alias.
+func (f *FileSystemPlayerStore) ResolvePlayer(name string) (string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.db.resolve(name), nil
+}
+
+// MergePlayers folds every win recorded for from into the player into, in
+// every season, and leaves from as an alias of into.
+func (f *FileSystemPlayerStore) MergePlayers(from, into string) error {
+	return f.update(func(db *playerDB) error {
+		source := db.resolve(from)
+		target := db.resolve(into)
+
+		if playerKey(source) == playerKey(target) {
+			return fmt.Errorf("%w: can't merge %s into themselves", ErrAliasConflict, source)
+		}
+
+		if !db.hasPlayer(source) {
+			return fmt.Errorf("%w: %s", ErrPlayerNotFound, source)
+		}
+
+		for i := range db.Seasons {
+			season := &db.Seasons[i]
+
+			if !season.has(source) {
+				continue
+			}
+
+			season.setWins(target, season.wins(target)+season.wins(source))
+			season.setWins(source, 0)
+		}
+
+		for alias, name := range db.Aliases {
+			if playerKey(name) == playerKey(source) {
+				db.Aliases[alias] = target
+			}
+		}
+		db.Aliases[playerKey(source)] = target
+
+		db.log(auditRecord{Action: mergeAction, Player: source, To: target})
+		return nil
+	})
+}
+
+func (db *playerDB) hasPlayer(name string) bool {
+	for _, season := range db.Seasons {
+		if season.has(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l League) without(name string) League {
+	key := playerKey(name)
+	kept := League{}
+
+	for _, p := range l {
+		if playerKey(p.Name) != key {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
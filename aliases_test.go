@@ -0,0 +1,201 @@
+package poker_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func TestPlayerNameNormalisation(t *testing.T) {
+	store := mustMakeAliasStore(t)
+
+	poker.MustRecordWin(t, store, "chris")
+	poker.MustRecordWin(t, store, " Chris  ")
+
+	poker.AssertScore(t, store, "CHRIS", 35)
+
+	want := []poker.Player{
+		{"Chris", 35},
+		{"Cleo", 10},
+	}
+	poker.AssertLeague(t, poker.MustGetLeague(t, store), want)
+}
+
+func TestFileSystemPlayerStoreAliases(t *testing.T) {
+	t.Run("records wins and looks up scores through an alias", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+		poker.AssertNoError(t, store.AddAlias("Chris", "CJ"))
+
+		poker.MustRecordWin(t, store, "cj")
+
+		poker.AssertScore(t, store, "CJ", 34)
+		poker.AssertScore(t, store, "Chris", 34)
+		assertAliases(t, store, "Chris", []string{"cj"})
+	})
+
+	t.Run("refuses an alias that is another player with wins", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		err := store.AddAlias("Chris", "cleo")
+
+		if !errors.Is(err, poker.ErrAliasConflict) {
+			t.Errorf("got error %v want %v", err, poker.ErrAliasConflict)
+		}
+	})
+
+	t.Run("refuses an alias that already belongs to someone else", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+		poker.AssertNoError(t, store.AddAlias("Chris", "CJ"))
+
+		err := store.AddAlias("Cleo", "CJ")
+
+		if !errors.Is(err, poker.ErrAliasConflict) {
+			t.Errorf("got error %v want %v", err, poker.ErrAliasConflict)
+		}
+	})
+
+	t.Run("merges wins in every season and leaves an alias behind", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+		poker.AssertNoError(t, store.StartSeason("2026-Q4"))
+		poker.MustRecordWin(t, store, "Cleo")
+		poker.MustRecordWin(t, store, "Chris")
+
+		poker.AssertNoError(t, store.MergePlayers("cleo", "Chris"))
+
+		poker.AssertScore(t, store, "Chris", 2)
+		poker.AssertScore(t, store, "Cleo", 2)
+		assertSeasonScore(t, store, "default", "Chris", 43)
+		assertAliases(t, store, "Chris", []string{"cleo"})
+
+		want := []poker.Player{
+			{"Chris", 2},
+		}
+		poker.AssertLeague(t, poker.MustGetLeague(t, store), want)
+	})
+
+	t.Run("refuses to merge a player with no wins", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		err := store.MergePlayers("Nobody", "Chris")
+
+		if !errors.Is(err, poker.ErrPlayerNotFound) {
+			t.Errorf("got error %v want %v", err, poker.ErrPlayerNotFound)
+		}
+	})
+
+	t.Run("keeps aliases across reopening the file", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `[{"Name": "Chris", "Wins": 33}]`)
+		defer cleanDatabase()
+
+		store, closeStore, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		poker.AssertNoError(t, store.AddAlias("Chris", "CJ"))
+		closeStore()
+
+		reopened, closeReopened, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeReopened()
+
+		poker.AssertScore(t, reopened, "cj", 33)
+	})
+}
+
+func TestAliasesOverHTTP(t *testing.T) {
+	store := mustMakeAliasStore(t)
+	server := mustMakePlayerServer(t, store, dummyGame)
+	server.AdminToken = adminToken
+
+	t.Run("POST /players/{name}/aliases/{alias} adds an alias", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewPostAliasRequest("Chris", "CJ", adminToken))
+		assertStatus(t, response, http.StatusAccepted)
+
+		response = httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetScoreRequest("CJ"))
+		poker.AssertResponseBody(t, response.Body.String(), "33")
+	})
+
+	t.Run("GET /players/{name}/aliases lists the aliases", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetAliasesRequest("Chris"))
+
+		assertStatus(t, response, http.StatusOK)
+		poker.AssertContentType(t, response, "application/json")
+		poker.AssertResponseBody(t, response.Body.String(), "[\"cj\"]\n")
+	})
+
+	t.Run("returns 409 for a conflicting alias", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewPostAliasRequest("Cleo", "CJ", adminToken))
+
+		assertStatus(t, response, http.StatusConflict)
+	})
+
+	t.Run("returns 404 when merging an unknown player", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewPostMergeRequest("Nobody", "Chris", adminToken))
+
+		assertStatus(t, response, http.StatusNotFound)
+	})
+
+	t.Run("POST /players/{name}/aliases/{alias} needs the admin token", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewPostAliasRequest("Cleo", "Cle", ""))
+
+		assertStatus(t, response, http.StatusUnauthorized)
+	})
+
+	t.Run("POST /players/{name}/merge-into/{into} needs the admin token", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewPostMergeRequest("Cleo", "Chris", ""))
+
+		assertStatus(t, response, http.StatusUnauthorized)
+	})
+
+	t.Run("POST /players/{name}/merge-into/{into} merges players", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewPostMergeRequest("Cleo", "Chris", adminToken))
+		assertStatus(t, response, http.StatusAccepted)
+
+		response = httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetScoreRequest("Chris"))
+		poker.AssertResponseBody(t, response.Body.String(), "43")
+	})
+
+	t.Run("returns 501 when the store has no aliases", func(t *testing.T) {
+		server := mustMakePlayerServer(t, &poker.StubPlayerStore{}, dummyGame)
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetAliasesRequest("Chris"))
+
+		assertStatus(t, response, http.StatusNotImplemented)
+	})
+}
+
+func mustMakeAliasStore(t *testing.T) *poker.FileSystemPlayerStore {
+	t.Helper()
+
+	database, cleanDatabase := createTempFile(t, `[
+      {"Name": "Cleo", "Wins": 10},
+      {"Name": "Chris", "Wins": 33}]`)
+	t.Cleanup(cleanDatabase)
+
+	store, err := poker.NewFileSystemPlayerStore(database)
+	poker.AssertNoError(t, err)
+
+	return store
+}
+
+func assertAliases(t *testing.T, store poker.AliasStore, player string, want []string) {
+	t.Helper()
+
+	got, err := store.GetAliases(player)
+	poker.AssertNoError(t, err)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got aliases %v for %s want %v", got, player, want)
+	}
+}
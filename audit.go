@@ -0,0 +1,296 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultKeepAudit caps the audit log, which is saved in the database file
+// and rewritten with it on every change.
+const defaultKeepAudit = 10000
+
+var (
+	ErrAuditEntryNotFound = errors.New("audit entry not found")
+	ErrCannotUndo         = errors.New("audit entry can't be undone")
+)
+
+// Source says where a change to a store came from. Remote is the address of
+// the client that asked for it, or "cli".
+type Source struct {
+	Kind   string
+	Remote string
+}
+
+const (
+	SourceCLI  = "cli"
+	SourceGame = "game"
+	SourceHTTP = "http"
+)
+
+var CLISource = Source{Kind: SourceCLI, Remote: "cli"}
+
+// GameSource is the source of a winner entered in the websocket game.
+func GameSource(remote string) Source {
+	return Source{Kind: SourceGame, Remote: remote}
+}
+
+// HTTPSource is the source of a change asked for directly over HTTP.
+func HTTPSource(remote string) Source {
+	return Source{Kind: SourceHTTP, Remote: remote}
+}
+
+// AuditStore is implemented by player stores that log every change made to
+// them. From returns a view of the store that logs changes as made by source.
+type AuditStore interface {
+	From(source Source) PlayerStore
+	GetAuditLog() ([]AuditEntry, error)
+	GetPlayerChanges(name string) ([]AuditEntry, error)
+	Undo(id int) error
+}
+
+// sourcedStore is implemented by audit stores, and by the stores wrapping
+// them, which pass the source on.
+type sourcedStore interface {
+	From(source Source) PlayerStore
+}
+
+// StoreFrom returns a view of store that logs changes as made by source, or
+// store itself when it keeps no audit log.
+func StoreFrom(store PlayerStore, source Source) PlayerStore {
+	if sourced, ok := store.(sourcedStore); ok {
+		return sourced.From(source)
+	}
+	return store
+}
+
+// AuditEntry is one change made to a store. Before and After are the player's
+// wins in Season either side of the change. An entry that reverts another
+// names it in Undoes, and the reverted entry names it in UndoneBy.
+type AuditEntry struct {
+	ID       int
+	At       time.Time
+	Source   Source
+	Action   string
+	Player   string
+	To       string
+	Season   string
+	Before   int
+	After    int
+	Reason   string
+	Undoes   int
+	UndoneBy int
+}
+
+const (
+	winAction         = "win"
+	renameAction      = "rename"
+	deleteAction      = "delete"
+	setScoreAction    = "set-score"
+	startSeasonAction = "start-season"
+	addAliasAction    = "add-alias"
+	mergeAction       = "merge"
+	undoAction        = "undo"
+	restoreAction     = "restore"
+)
+
+// auditRecord is the on-disk form of an AuditEntry.
+type auditRecord struct {
+	ID       int       `json:"id"`
+	At       time.Time `json:"at"`
+	Source   string    `json:"source,omitempty"`
+	Remote   string    `json:"remote,omitempty"`
+	Action   string    `json:"action"`
+	Player   string    `json:"player,omitempty"`
+	To       string    `json:"to,omitempty"`
+	Season   string    `json:"season"`
+	Before   int       `json:"before"`
+	After    int       `json:"after"`
+	Reason   string    `json:"reason,omitempty"`
+	Undoes   int       `json:"undoes,omitempty"`
+	UndoneBy int       `json:"undoneBy,omitempty"`
+}
+
+func (r auditRecord) entry() AuditEntry {
+	return AuditEntry{
+		ID:       r.ID,
+		At:       r.At,
+		Source:   Source{Kind: r.Source, Remote: r.Remote},
+		Action:   r.Action,
+		Player:   r.Player,
+		To:       r.To,
+		Season:   r.Season,
+		Before:   r.Before,
+		After:    r.After,
+		Reason:   r.Reason,
+		Undoes:   r.Undoes,
+		UndoneBy: r.UndoneBy,
+	}
+}
+
+// From returns a view of the store that shares its data but logs the changes
+// made through it as made by source.
+func (f *FileSystemPlayerStore) From(source Source) PlayerStore {
+	return &FileSystemPlayerStore{playerFile: f.playerFile, source: source}
+}
+
+// GetAuditLog returns the changes kept in the audit log, oldest first.
+func (f *FileSystemPlayerStore) GetAuditLog() ([]AuditEntry, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	entries := make([]AuditEntry, len(f.db.Audit))
+	for i, record := range f.db.Audit {
+		entries[i] = record.entry()
+	}
+	return entries, nil
+}
+
+// GetPlayerChanges returns the changes made to a player, oldest first.
+func (f *FileSystemPlayerStore) GetPlayerChanges(name string) ([]AuditEntry, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	key := playerKey(f.db.resolve(name))
+	entries := []AuditEntry{}
+
+	for _, record := range f.db.Audit {
+		if playerKey(record.Player) == key || playerKey(record.To) == key {
+			entries = append(entries, record.entry())
+		}
+	}
+
+	return entries, nil
+}
+
+// Undo reverts the change logged as id. Changes to scores are reverted by
+// the amount they changed the score by, so later wins are kept. Changes
+// dropped from the log, see KeepAudit, are no longer found.
+func (f *FileSystemPlayerStore) Undo(id int) error {
+	return f.update(func(db *playerDB) error {
+		i := db.findAudit(id)
+
+		if i < 0 {
+			return fmt.Errorf("%w: %d", ErrAuditEntryNotFound, id)
+		}
+
+		entry := db.Audit[i]
+
+		if entry.UndoneBy != 0 {
+			return fmt.Errorf("%w: %d was already undone by %d", ErrCannotUndo, id, entry.UndoneBy)
+		}
+
+		var undo auditRecord
+		var err error
+
+		switch entry.Action {
+		case winAction, deleteAction, setScoreAction:
+			undo, err = db.revertScore(entry)
+		case renameAction:
+			undo, err = db.revertRename(entry)
+		case addAliasAction:
+			undo, err = db.revertAlias(entry)
+		default:
+			err = fmt.Errorf("%w: %s changes can't be undone", ErrCannotUndo, entry.Action)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		undo.Action = undoAction
+		undo.Undoes = id
+		db.Audit = slices.Clone(db.Audit)
+		db.Audit[i].UndoneBy = db.log(undo)
+		return nil
+	})
+}
+
+func (db *playerDB) revertScore(entry auditRecord) (auditRecord, error) {
+	season := db.findSeason(entry.Season)
+	player := db.resolve(entry.Player)
+
+	if season == nil {
+		return auditRecord{}, fmt.Errorf("%w: %v", ErrCannotUndo, ErrSeasonNotFound)
+	}
+
+	before := season.wins(player)
+	after := before + entry.Before - entry.After
+
+	if after < 0 {
+		return auditRecord{}, fmt.Errorf("%w: %s only has %d wins in %s", ErrCannotUndo, player, before, season.Name)
+	}
+
+	season.setWins(player, after)
+	return auditRecord{Player: player, Season: season.Name, Before: before, After: after}, nil
+}
+
+func (db *playerDB) revertRename(entry auditRecord) (auditRecord, error) {
+	if err := db.rename(entry.To, entry.Player); err != nil {
+		return auditRecord{}, fmt.Errorf("%w: %v", ErrCannotUndo, err)
+	}
+
+	wins := db.activeWins(entry.Player)
+	return auditRecord{Player: entry.To, To: entry.Player, Before: wins, After: wins}, nil
+}
+
+func (db *playerDB) revertAlias(entry auditRecord) (auditRecord, error) {
+	key := playerKey(entry.To)
+
+	if player, ok := db.Aliases[key]; !ok || playerKey(player) != playerKey(entry.Player) {
+		return auditRecord{}, fmt.Errorf("%w: %s is no longer an alias of %s", ErrCannotUndo, entry.To, entry.Player)
+	}
+
+	delete(db.Aliases, key)
+	return auditRecord{Player: entry.Player, To: entry.To}, nil
+}
+
+// log appends record to the audit log, in the active season unless it names
+// another, and returns its ID. The time and source are filled in by
+// stampAudit when the change is saved.
+func (db *playerDB) log(record auditRecord) int {
+	record.ID = 1
+	if len(db.Audit) > 0 {
+		record.ID = db.Audit[len(db.Audit)-1].ID + 1
+	}
+
+	if record.Season == "" {
+		record.Season = db.activeSeason().Name
+	}
+
+	record.Reason = strings.TrimSpace(record.Reason)
+
+	db.Audit = append(db.Audit, record)
+	db.logged++
+	return record.ID
+}
+
+func (db *playerDB) stampAudit(source Source) {
+	now := time.Now().UTC()
+
+	for i := len(db.Audit) - db.logged; i < len(db.Audit); i++ {
+		db.Audit[i].At = now
+		db.Audit[i].Source = source.Kind
+		db.Audit[i].Remote = source.Remote
+	}
+}
+
+// trimAudit drops the oldest entries so the log holds at most keep. The log
+// is shared with the database's copies, so it's resliced rather than moved
+// along in place.
+func (db *playerDB) trimAudit(keep int) {
+	if keep > 0 && len(db.Audit) > keep {
+		db.Audit = db.Audit[len(db.Audit)-keep:]
+	}
+}
+
+func (db *playerDB) findAudit(id int) int {
+	for i, record := range db.Audit {
+		if record.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,254 @@
+package poker_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func TestFileSystemPlayerStoreAuditLog(t *testing.T) {
+	t.Run("logs each win with where it came from", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		poker.MustRecordWin(t, store.From(poker.HTTPSource("192.0.2.1:1234")), "Chris")
+
+		entry := lastAuditEntry(t, store)
+
+		if entry.Action != "win" || entry.Player != "Chris" || entry.Before != 33 || entry.After != 34 {
+			t.Errorf("got audit entry %+v want a win for Chris", entry)
+		}
+
+		if entry.Source != poker.HTTPSource("192.0.2.1:1234") {
+			t.Errorf("got source %+v want %+v", entry.Source, poker.HTTPSource("192.0.2.1:1234"))
+		}
+
+		if entry.At.IsZero() {
+			t.Error("expected the entry to be timestamped")
+		}
+	})
+
+	t.Run("logs wins from a finished game as coming from the game", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+		game := poker.NewTexasHoldem(dummyBlindAlerter, store, &poker.StubGameStore{})
+
+		poker.AssertNoError(t, mustStartGame(t, game, 2, nil).Finish("Cleo", poker.GameSource("192.0.2.1:1234")))
+
+		if entry := lastAuditEntry(t, store); entry.Source.Kind != poker.SourceGame || entry.Player != "Cleo" {
+			t.Errorf("got audit entry %+v want a game win for Cleo", entry)
+		}
+	})
+
+	t.Run("undoes one win and keeps the wins recorded after it", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+		poker.MustRecordWin(t, store, "Chris")
+		mistake := lastAuditEntry(t, store)
+		poker.MustRecordWin(t, store, "Chris")
+
+		poker.AssertNoError(t, store.Undo(mistake.ID))
+
+		poker.AssertScore(t, store, "Chris", 34)
+
+		undo := lastAuditEntry(t, store)
+		if undo.Action != "undo" || undo.Undoes != mistake.ID {
+			t.Errorf("got audit entry %+v want the undo of %d", undo, mistake.ID)
+		}
+	})
+
+	t.Run("refuses to undo an entry twice", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+		poker.MustRecordWin(t, store, "Chris")
+		win := lastAuditEntry(t, store)
+
+		poker.AssertNoError(t, store.Undo(win.ID))
+		err := store.Undo(win.ID)
+
+		if !errors.Is(err, poker.ErrCannotUndo) {
+			t.Errorf("got error %v want %v", err, poker.ErrCannotUndo)
+		}
+		poker.AssertScore(t, store, "Chris", 33)
+	})
+
+	t.Run("undoes corrections", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		poker.AssertNoError(t, store.DeletePlayer("Cleo", "mistake"))
+		poker.AssertNoError(t, store.Undo(lastAuditEntry(t, store).ID))
+		poker.AssertScore(t, store, "Cleo", 10)
+
+		poker.AssertNoError(t, store.SetPlayerScore("Chris", 3, "mistake"))
+		poker.AssertNoError(t, store.Undo(lastAuditEntry(t, store).ID))
+		poker.AssertScore(t, store, "Chris", 33)
+
+		poker.AssertNoError(t, store.RenamePlayer("Chris", "Kris", "mistake"))
+		poker.AssertNoError(t, store.Undo(lastAuditEntry(t, store).ID))
+		poker.AssertScore(t, store, "Chris", 33)
+		poker.AssertScore(t, store, "Kris", 0)
+	})
+
+	t.Run("refuses to undo a merge", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+		poker.AssertNoError(t, store.MergePlayers("Cleo", "Chris"))
+
+		err := store.Undo(lastAuditEntry(t, store).ID)
+
+		if !errors.Is(err, poker.ErrCannotUndo) {
+			t.Errorf("got error %v want %v", err, poker.ErrCannotUndo)
+		}
+	})
+
+	t.Run("doesn't log a change that fails part way", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+		poker.MustRecordWin(t, store, "Cleo")
+
+		_, err := store.ImportLeague(poker.League{{"Ruth", 3}, {"ruth", 2}}, poker.ImportOptions{})
+		assertErrorIs(t, err, poker.ErrInvalidImport)
+
+		poker.MustRecordWin(t, store, "Chris")
+
+		entries, err := store.GetAuditLog()
+		poker.AssertNoError(t, err)
+
+		if len(entries) != 2 || entries[0].Player != "Cleo" || entries[1].Player != "Chris" {
+			t.Errorf("got audit log %+v want just the two wins", entries)
+		}
+	})
+
+	t.Run("drops the oldest entries past the number kept", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+		store.KeepAudit = 2
+
+		poker.MustRecordWin(t, store, "Chris")
+		dropped := lastAuditEntry(t, store)
+		poker.MustRecordWin(t, store, "Chris")
+		poker.MustRecordWin(t, store, "Cleo")
+
+		entries, err := store.GetAuditLog()
+		poker.AssertNoError(t, err)
+
+		if len(entries) != 2 || entries[0].ID != dropped.ID+1 || entries[1].ID != dropped.ID+2 {
+			t.Errorf("got audit log %+v want the last two wins", entries)
+		}
+
+		assertErrorIs(t, store.Undo(dropped.ID), poker.ErrAuditEntryNotFound)
+		poker.AssertScore(t, store, "Chris", 35)
+	})
+
+	t.Run("returns an error for an unknown entry", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		err := store.Undo(99)
+
+		if !errors.Is(err, poker.ErrAuditEntryNotFound) {
+			t.Errorf("got error %v want %v", err, poker.ErrAuditEntryNotFound)
+		}
+	})
+}
+
+func TestAuditOverHTTP(t *testing.T) {
+	store := mustMakeAliasStore(t)
+	server := mustMakePlayerServer(t, store, dummyGame)
+	server.AdminToken = adminToken
+
+	server.ServeHTTP(httptest.NewRecorder(), poker.NewPostWinRequest("Cleo"))
+	server.ServeHTTP(httptest.NewRecorder(), poker.NewPostWinRequest("Chris"))
+
+	t.Run("GET /audit lists every change with its source", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetAuditRequest(""))
+
+		assertStatus(t, response, http.StatusOK)
+		poker.AssertContentType(t, response, "application/json")
+
+		entries := getAuditFromResponse(t, response.Body)
+
+		if len(entries) != 2 {
+			t.Fatalf("got %d entries want %d", len(entries), 2)
+		}
+
+		if entries[0].Source.Kind != poker.SourceHTTP {
+			t.Errorf("got source %+v want %q", entries[0].Source, poker.SourceHTTP)
+		}
+	})
+
+	t.Run("GET /audit filters by player and limits to the latest entries", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetAuditRequest("player=cleo"))
+
+		if entries := getAuditFromResponse(t, response.Body); len(entries) != 1 || entries[0].Player != "Cleo" {
+			t.Errorf("got entries %+v want Cleo's win", entries)
+		}
+
+		response = httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetAuditRequest("limit=1"))
+
+		if entries := getAuditFromResponse(t, response.Body); len(entries) != 1 || entries[0].Player != "Chris" {
+			t.Errorf("got entries %+v want Chris's win", entries)
+		}
+	})
+
+	t.Run("POST /audit/{id}/undo reverts the entry", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewUndoRequest(1, adminToken))
+
+		assertStatus(t, response, http.StatusAccepted)
+		poker.AssertScore(t, store, "Cleo", 10)
+	})
+
+	t.Run("returns 409 when the entry was already undone", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewUndoRequest(1, adminToken))
+
+		assertStatus(t, response, http.StatusConflict)
+	})
+
+	t.Run("returns 404 for an unknown entry", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewUndoRequest(99, adminToken))
+
+		assertStatus(t, response, http.StatusNotFound)
+	})
+
+	t.Run("POST /audit/{id}/undo needs the admin token", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewUndoRequest(2, ""))
+
+		assertStatus(t, response, http.StatusUnauthorized)
+	})
+
+	t.Run("returns 501 when the store keeps no audit log", func(t *testing.T) {
+		server := mustMakePlayerServer(t, &poker.StubPlayerStore{}, dummyGame)
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetAuditRequest(""))
+
+		assertStatus(t, response, http.StatusNotImplemented)
+	})
+}
+
+func lastAuditEntry(t *testing.T, store poker.AuditStore) poker.AuditEntry {
+	t.Helper()
+
+	entries, err := store.GetAuditLog()
+	poker.AssertNoError(t, err)
+
+	if len(entries) == 0 {
+		t.Fatal("expected the audit log to have entries")
+	}
+
+	return entries[len(entries)-1]
+}
+
+func getAuditFromResponse(t *testing.T, body io.Reader) []poker.AuditEntry {
+	t.Helper()
+
+	var entries []poker.AuditEntry
+	if err := json.NewDecoder(body).Decode(&entries); err != nil {
+		t.Fatalf("could not parse audit log, %v", err)
+	}
+
+	return entries
+}
@@ -0,0 +1,761 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func runCommand(name string, args []string) error {
+	switch name {
+	case "migrate":
+		return migrate(args)
+	case "import":
+		return importJSON(args)
+	case "league":
+		return showWindowLeague(args)
+	case "new-season":
+		return newSeason(args)
+	case "alias":
+		return addAlias(args)
+	case "aliases":
+		return listAliases(args)
+	case "merge":
+		return mergePlayers(args)
+	case "rename":
+		return renamePlayer(args)
+	case "delete":
+		return deletePlayer(args)
+	case "set-score":
+		return setScore(args)
+	case "audit":
+		return showAudit(args)
+	case "undo":
+		return undo(args)
+	case "recompute-ratings":
+		return recomputeRatings(args)
+	case poker.BuyIn, poker.Rebuy, poker.CashOut:
+		return recordChips(name, args)
+	case "pay":
+		return pay(args)
+	case "balances":
+		return showBalances(args)
+	case "settle-up":
+		return settleUp(args)
+	case "admin":
+		return admin(args)
+	case "export":
+		return export(args)
+	case "import-results":
+		return importResults(args)
+	case "generate-key":
+		return generateKey(args)
+	case "encrypt-db":
+		return encryptDB(args)
+	case "rotate-key":
+		return rotateKey(args)
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+func migrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "report what would change without writing")
+	flags.Parse(args)
+
+	if *storeBackend != poker.JSONBackend {
+		return fmt.Errorf("migrate only applies to the %s store", poker.JSONBackend)
+	}
+
+	options, err := storeOptions()
+
+	if err != nil {
+		return err
+	}
+
+	report, err := poker.MigratePlayerDBFile(dbPath(), *dryRun, options...)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(report)
+
+	if *dryRun {
+		fmt.Println("dry run, nothing was written")
+	}
+	return nil
+}
+
+func importJSON(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	from := flags.String("from", poker.DefaultDBFile(poker.JSONBackend), "json database to import")
+	flags.Parse(args)
+
+	if *storeBackend != poker.SQLiteBackend {
+		return fmt.Errorf("import only applies to the %s store", poker.SQLiteBackend)
+	}
+
+	store, close, err := poker.SQLPlayerStoreFromFile(dbPath())
+
+	if err != nil {
+		return err
+	}
+	defer close()
+
+	imported, err := store.ImportFromFile(*from)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("imported %d players from %s into %s\n", imported, *from, dbPath())
+	return nil
+}
+
+func showWindowLeague(args []string) error {
+	flags := flag.NewFlagSet("league", flag.ExitOnError)
+	name := flags.String("window", "", "week, month or rolling-Nd")
+	from := flags.String("from", "", "count wins from this date or RFC 3339 time")
+	to := flags.String("to", "", "count wins up to, but not including, this date or time")
+	flags.Parse(args)
+
+	if *name != "" && (*from != "" || *to != "") {
+		return fmt.Errorf("give a -window or -from and -to, not both")
+	}
+
+	window, err := poker.NewWindow(*from, *to)
+
+	if *name != "" {
+		window, err = poker.ParseWindow(*name, time.Now())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return withStore("league windows", func(windows poker.WindowStore) error {
+		league, err := windows.GetWindowLeague(window)
+
+		if err != nil {
+			return err
+		}
+
+		ranks := league.Ranks()
+
+		for i, player := range league {
+			fmt.Printf("%d\t%s\t%d\n", ranks[i], player.Name, player.Wins)
+		}
+		return nil
+	})
+}
+
+func newSeason(args []string) error {
+	flags := flag.NewFlagSet("new-season", flag.ExitOnError)
+	name := flags.String("name", "", "name of the season to start")
+	flags.Parse(args)
+
+	return withStore("seasons", func(seasons poker.SeasonStore) error {
+		if err := seasons.StartSeason(*name); err != nil {
+			return err
+		}
+
+		fmt.Printf("closed the previous season and started %s\n", *name)
+		return nil
+	})
+}
+
+func addAlias(args []string) error {
+	flags := flag.NewFlagSet("alias", flag.ExitOnError)
+	player := flags.String("player", "", "player the alias resolves to")
+	alias := flags.String("alias", "", "other name the player goes by")
+	flags.Parse(args)
+
+	return withStore("aliases", func(aliases poker.AliasStore) error {
+		if err := aliases.AddAlias(*player, *alias); err != nil {
+			return err
+		}
+
+		fmt.Printf("%s now resolves to %s\n", *alias, *player)
+		return nil
+	})
+}
+
+func listAliases(args []string) error {
+	flags := flag.NewFlagSet("aliases", flag.ExitOnError)
+	player := flags.String("player", "", "player to list the aliases of")
+	flags.Parse(args)
+
+	return withStore("aliases", func(aliases poker.AliasStore) error {
+		list, err := aliases.GetAliases(*player)
+
+		if err != nil {
+			return err
+		}
+
+		for _, alias := range list {
+			fmt.Println(alias)
+		}
+		return nil
+	})
+}
+
+func mergePlayers(args []string) error {
+	flags := flag.NewFlagSet("merge", flag.ExitOnError)
+	from := flags.String("from", "", "player whose wins are folded in")
+	into := flags.String("into", "", "player who keeps the wins")
+	flags.Parse(args)
+
+	return withStore("aliases", func(aliases poker.AliasStore) error {
+		if err := aliases.MergePlayers(*from, *into); err != nil {
+			return err
+		}
+
+		fmt.Printf("merged %s into %s\n", *from, *into)
+		return nil
+	})
+}
+
+func renamePlayer(args []string) error {
+	flags := flag.NewFlagSet("rename", flag.ExitOnError)
+	player := flags.String("player", "", "player to rename")
+	to := flags.String("to", "", "new name of the player")
+	reason := flags.String("reason", "", "why the player is being renamed")
+	flags.Parse(args)
+
+	return withStore("correcting players", func(admin poker.PlayerAdminStore) error {
+		if err := admin.RenamePlayer(*player, *to, *reason); err != nil {
+			return err
+		}
+
+		fmt.Printf("renamed %s to %s\n", *player, *to)
+		return nil
+	})
+}
+
+func deletePlayer(args []string) error {
+	flags := flag.NewFlagSet("delete", flag.ExitOnError)
+	player := flags.String("player", "", "player to remove from the active season")
+	reason := flags.String("reason", "", "why the player is being removed")
+	flags.Parse(args)
+
+	return withStore("correcting players", func(admin poker.PlayerAdminStore) error {
+		if err := admin.DeletePlayer(*player, *reason); err != nil {
+			return err
+		}
+
+		fmt.Printf("removed %s from the active season\n", *player)
+		return nil
+	})
+}
+
+func setScore(args []string) error {
+	flags := flag.NewFlagSet("set-score", flag.ExitOnError)
+	player := flags.String("player", "", "player whose wins are corrected")
+	wins := flags.Int("wins", 0, "wins the player should have in the active season")
+	adjust := flags.Bool("adjust", false, "add -wins, which may be negative, instead of setting it")
+	reason := flags.String("reason", "", "why the score is being corrected")
+	flags.Parse(args)
+
+	return withStore("correcting players", func(admin poker.PlayerAdminStore) error {
+		correct := admin.SetPlayerScore
+		if *adjust {
+			correct = admin.AdjustPlayerScore
+		}
+
+		if err := correct(*player, *wins, *reason); err != nil {
+			return err
+		}
+
+		fmt.Printf("corrected the score of %s\n", *player)
+		return nil
+	})
+}
+
+func showAudit(args []string) error {
+	flags := flag.NewFlagSet("audit", flag.ExitOnError)
+	player := flags.String("player", "", "only show the changes made to this player")
+	limit := flags.Int("limit", 20, "number of latest entries to show, 0 for all")
+	flags.Parse(args)
+
+	return withStore("an audit log", func(audit poker.AuditStore) error {
+		entries, err := audit.GetAuditLog()
+		if *player != "" {
+			entries, err = audit.GetPlayerChanges(*player)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if *limit > 0 && *limit < len(entries) {
+			entries = entries[len(entries)-*limit:]
+		}
+
+		for _, entry := range entries {
+			fmt.Println(formatAuditEntry(entry))
+		}
+		return nil
+	})
+}
+
+func formatAuditEntry(entry poker.AuditEntry) string {
+	line := fmt.Sprintf("%d\t%s\t%s %s\t%s", entry.ID, entry.At.Local().Format("2006-01-02 15:04"), entry.Source.Kind, entry.Source.Remote, entry.Action)
+
+	if entry.Player != "" {
+		line += " " + entry.Player
+	}
+	if entry.To != "" {
+		line += " -> " + entry.To
+	}
+	if entry.Before != entry.After {
+		line += fmt.Sprintf(" (%s: %d -> %d)", entry.Season, entry.Before, entry.After)
+	}
+	if entry.Undoes != 0 {
+		line += fmt.Sprintf(" undoing %d", entry.Undoes)
+	}
+	if entry.UndoneBy != 0 {
+		line += fmt.Sprintf(" [undone by %d]", entry.UndoneBy)
+	}
+	if entry.Reason != "" {
+		line += ": " + entry.Reason
+	}
+	return line
+}
+
+func undo(args []string) error {
+	flags := flag.NewFlagSet("undo", flag.ExitOnError)
+	id := flags.Int("id", 0, "audit log entry to revert")
+	flags.Parse(args)
+
+	return withStore("an audit log", func(audit poker.AuditStore) error {
+		if err := audit.Undo(*id); err != nil {
+			return err
+		}
+
+		fmt.Printf("reverted audit entry %d\n", *id)
+		return nil
+	})
+}
+
+func recomputeRatings(args []string) error {
+	flags := flag.NewFlagSet("recompute-ratings", flag.ExitOnError)
+	flags.Parse(args)
+
+	games, close, err := openGameStore()
+
+	if err != nil {
+		return err
+	}
+	defer close()
+
+	if err := games.RecomputeRatings(); err != nil {
+		return err
+	}
+
+	ratings, err := games.GetRatings()
+
+	if err != nil {
+		return err
+	}
+
+	for _, rating := range ratings {
+		fmt.Printf("%s\t%.0f\t(%d games)\n", rating.Name, rating.Rating, rating.Games)
+	}
+	return nil
+}
+
+func recordChips(kind string, args []string) error {
+	flags := flag.NewFlagSet(kind, flag.ExitOnError)
+	game := flags.Int("game", 0, "id of the game, see /games, or of a game being played as it was given when the game started")
+	player := flags.String("player", "", "player the money belongs to")
+	amount := flags.Int("amount", 0, "amount in the smallest unit of the stakes")
+	flags.Parse(args)
+
+	return withLedger(func(ledger poker.LedgerStore) error {
+		entry, err := ledger.RecordLedgerEntry(poker.LedgerEntry{GameID: *game, Kind: kind, Player: *player, Amount: *amount})
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("recorded %s of %d for %s in game %d\n", entry.Kind, entry.Amount, entry.Player, entry.GameID)
+		return nil
+	})
+}
+
+func pay(args []string) error {
+	flags := flag.NewFlagSet("pay", flag.ExitOnError)
+	from := flags.String("from", "", "player who paid")
+	to := flags.String("to", "", "player who was paid")
+	amount := flags.Int("amount", 0, "amount in the smallest unit of the stakes")
+	flags.Parse(args)
+
+	return withLedger(func(ledger poker.LedgerStore) error {
+		entry, err := ledger.RecordLedgerEntry(poker.LedgerEntry{Kind: poker.Payment, Player: *from, To: *to, Amount: *amount})
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("recorded %s paying %s %d\n", entry.Player, entry.To, entry.Amount)
+		return nil
+	})
+}
+
+func showBalances(args []string) error {
+	flags := flag.NewFlagSet("balances", flag.ExitOnError)
+	flags.Parse(args)
+
+	return withLedger(func(ledger poker.LedgerStore) error {
+		balances, err := ledger.GetBalances()
+
+		if err != nil {
+			return err
+		}
+
+		for _, balance := range balances {
+			fmt.Printf("%s\t%d\n", balance.Player, balance.Balance)
+		}
+		return nil
+	})
+}
+
+func settleUp(args []string) error {
+	flags := flag.NewFlagSet("settle-up", flag.ExitOnError)
+	flags.Parse(args)
+
+	return withLedger(func(ledger poker.LedgerStore) error {
+		balances, err := ledger.GetBalances()
+
+		if err != nil {
+			return err
+		}
+
+		transfers, err := poker.SettleUp(balances)
+
+		if err != nil {
+			return err
+		}
+
+		if len(transfers) == 0 {
+			fmt.Println("everyone is square")
+		}
+
+		for _, transfer := range transfers {
+			fmt.Printf("%s pays %s %d\n", transfer.From, transfer.To, transfer.Amount)
+		}
+		return nil
+	})
+}
+
+func withLedger(run func(poker.LedgerStore) error) error {
+	games, close, err := openGameStore()
+
+	if err != nil {
+		return err
+	}
+	defer close()
+
+	return run(games)
+}
+
+func export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	data := flags.String("data", "league", "what to export: league or games")
+	format := flags.String("format", poker.CSVFormat, "csv or json")
+	out := flags.String("out", "", "file to write, defaults to stdout")
+	flags.Parse(args)
+
+	w := os.Stdout
+
+	if *out != "" {
+		file, err := os.Create(*out)
+
+		if err != nil {
+			return fmt.Errorf("problem creating %s, %v", *out, err)
+		}
+		defer file.Close()
+
+		w = file
+	}
+
+	switch *data {
+	case "league":
+		return withStore("exporting", func(store poker.PlayerStore) error {
+			league, err := store.GetLeague()
+
+			if err != nil {
+				return err
+			}
+
+			return poker.WriteLeague(w, league, *format)
+		})
+	case "games":
+		games, close, err := openGameStore()
+
+		if err != nil {
+			return err
+		}
+		defer close()
+
+		history, err := games.GetGames()
+
+		if err != nil {
+			return err
+		}
+
+		return poker.WriteGames(w, history, *format)
+	default:
+		return fmt.Errorf("can't export %q, want league or games", *data)
+	}
+}
+
+func importResults(args []string) error {
+	flags := flag.NewFlagSet("import-results", flag.ExitOnError)
+	from := flags.String("file", "", "csv or json league to import, read as the file's extension says")
+	conflicts := flags.String("conflicts", poker.KeepExisting, "how to settle players whose wins differ: keep, replace or add")
+	dryRun := flags.Bool("dry-run", false, "report what would change without writing")
+	flags.Parse(args)
+
+	file, err := os.Open(*from)
+
+	if err != nil {
+		return fmt.Errorf("problem opening %s, %v", *from, err)
+	}
+	defer file.Close()
+
+	league, err := poker.ReadLeague(file, strings.TrimPrefix(strings.ToLower(filepath.Ext(*from)), "."))
+
+	if err != nil {
+		return err
+	}
+
+	return withStore("importing results", func(importer poker.ImportStore) error {
+		report, err := importer.ImportLeague(league, poker.ImportOptions{Conflicts: *conflicts, DryRun: *dryRun, Reason: "imported " + *from})
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(report)
+
+		if *dryRun {
+			fmt.Println("dry run, nothing was written")
+		}
+		return nil
+	})
+}
+
+func admin(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("admin needs a command: snapshot, snapshots or restore")
+	}
+
+	switch args[0] {
+	case "snapshot":
+		return takeSnapshot(args[1:])
+	case "snapshots":
+		return listSnapshots(args[1:])
+	case "restore":
+		return restoreSnapshot(args[1:])
+	default:
+		return fmt.Errorf("unknown admin command %q", args[0])
+	}
+}
+
+func takeSnapshot(args []string) error {
+	flags := flag.NewFlagSet("admin snapshot", flag.ExitOnError)
+	keep := flags.Int("keep", 10, "number of snapshots to keep, 0 keeps them all")
+	flags.Parse(args)
+
+	return withStore("snapshots", func(store *poker.FileSystemPlayerStore) error {
+		store.KeepSnapshots = *keep
+		snapshot, err := store.TakeSnapshot()
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("took snapshot %s at %s\n", snapshot.Name, snapshot.Path)
+		return nil
+	})
+}
+
+func listSnapshots(args []string) error {
+	flags := flag.NewFlagSet("admin snapshots", flag.ExitOnError)
+	flags.Parse(args)
+
+	return withStore("snapshots", func(snapshots poker.SnapshotStore) error {
+		kept, err := snapshots.GetSnapshots()
+
+		if err != nil {
+			return err
+		}
+
+		for _, snapshot := range kept {
+			fmt.Printf("%s\t%d bytes\n", snapshot.Name, snapshot.Size)
+		}
+		return nil
+	})
+}
+
+func restoreSnapshot(args []string) error {
+	flags := flag.NewFlagSet("admin restore", flag.ExitOnError)
+	name := flags.String("name", "", "snapshot to restore, as listed by admin snapshots")
+	flags.Parse(args)
+
+	return withStore("snapshots", func(snapshots poker.SnapshotStore) error {
+		if err := snapshots.Restore(*name); err != nil {
+			return err
+		}
+
+		fmt.Printf("restored snapshot %s, the data it replaced was snapshotted first\n", *name)
+		return nil
+	})
+}
+
+func generateKey(args []string) error {
+	flags := flag.NewFlagSet("generate-key", flag.ExitOnError)
+	out := flags.String("out", "", "file to write the key to, readable only by its owner, instead of printing it")
+	flags.Parse(args)
+
+	key, err := poker.GenerateKey()
+
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		fmt.Println(key)
+		return nil
+	}
+
+	return os.WriteFile(*out, []byte(key+"\n"), 0600)
+}
+
+func encryptDB(args []string) error {
+	flags := flag.NewFlagSet("encrypt-db", flag.ExitOnError)
+	flags.Parse(args)
+
+	key, err := poker.LoadKey(*keyFile)
+
+	if err != nil {
+		return err
+	}
+
+	if key == nil {
+		return fmt.Errorf("encrypt-db needs a key in -key-file or $%s, see generate-key", poker.DBKeyEnv)
+	}
+
+	return rekeyStores(nil, key, "encrypted")
+}
+
+func rotateKey(args []string) error {
+	flags := flag.NewFlagSet("rotate-key", flag.ExitOnError)
+	newKeyFile := flags.String("new-key-file", "", "file holding the key to encrypt with from now on")
+	flags.Parse(args)
+
+	oldKey, err := poker.LoadKey(*keyFile)
+
+	if err != nil {
+		return err
+	}
+
+	if oldKey == nil {
+		return fmt.Errorf("rotate-key needs the current key in -key-file or $%s", poker.DBKeyEnv)
+	}
+
+	if *newKeyFile == "" {
+		return fmt.Errorf("rotate-key needs a -new-key-file, see generate-key")
+	}
+
+	data, err := os.ReadFile(*newKeyFile)
+
+	if err != nil {
+		return fmt.Errorf("problem reading key file %s, %v", *newKeyFile, err)
+	}
+
+	newKey, err := poker.ParseKey(string(data))
+
+	if err != nil {
+		return err
+	}
+
+	if err := rekeyStores(oldKey, newKey, "re-encrypted"); err != nil {
+		return err
+	}
+
+	fmt.Printf("use %s as the key from now on\n", *newKeyFile)
+	return nil
+}
+
+// rekeyStores re-encrypts the player database and the game history, with
+// their backups and snapshots. Nothing else should have them open.
+func rekeyStores(oldKey, newKey []byte, done string) error {
+	if *storeBackend != poker.JSONBackend {
+		return fmt.Errorf("only the %s store can be encrypted", poker.JSONBackend)
+	}
+
+	var paths []string
+
+	for _, path := range []string{dbPath(), *gamesFile} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			paths = append(paths, path)
+		}
+	}
+
+	// both are checked against the old key before either is written, a key
+	// that opens only one of them leaves both as they were
+	if err := poker.RekeyFiles(oldKey, newKey, paths...); err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		fmt.Printf("%s %s\n", done, path)
+	}
+
+	return nil
+}
+
+// withStore opens the configured player store and runs run against it when
+// the store supports the feature T describes. Changes are logged as made from
+// the cli in stores that keep an audit log.
+func withStore[T any](feature string, run func(T) error) error {
+	options, err := storeOptions()
+
+	if err != nil {
+		return err
+	}
+
+	store, close, err := poker.OpenPlayerStore(*storeBackend, *dbFile, options...)
+
+	if err != nil {
+		return err
+	}
+	defer close()
+
+	supported, ok := poker.StoreFrom(store, poker.CLISource).(T)
+
+	if !ok {
+		return fmt.Errorf("the %s store does not support %s", *storeBackend, feature)
+	}
+
+	return run(supported)
+}
+
+func openGameStore() (*poker.FileSystemGameStore, func(), error) {
+	options, err := storeOptions()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return poker.FileSystemGameStoreFromFile(*gamesFile, options...)
+}
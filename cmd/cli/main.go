@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -8,22 +9,82 @@ import (
 	poker "github.com/ljones140/golang-player-webserver"
 )
 
-const dbFileName = "game.db.json"
+var (
+	storeBackend = flag.String("store", poker.JSONBackend, "player store to use: json, eventlog or sqlite, json rewrites the whole file on every write so use eventlog or sqlite for large leagues")
+	dbFile       = flag.String("db", "", "database file, defaults to game.db.json, game.db.log or game.db.sqlite for the chosen store")
+	gamesFile    = flag.String("games", "game.history.json", "game history file")
+	keyFile      = flag.String("key-file", "", "file holding the key the databases are encrypted with, defaults to $"+poker.DBKeyEnv)
+)
 
 func main() {
+	flag.Parse()
+
+	if flag.NArg() > 0 {
+		if err := runCommand(flag.Arg(0), flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	fmt.Println("Let's play poker")
 	fmt.Println("Type {name} wins to record a win")
 
-	store, close, err := poker.FileSystemPlayerStoreFromFile(dbFileName)
+	options, err := storeOptions()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	store, close, err := poker.OpenPlayerStore(*storeBackend, *dbFile, options...)
 
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer close()
 
-	game := poker.NewTexasHoldem(poker.BlindAlerterFunc(poker.Alerter), store)
+	if fileStore, ok := store.(*poker.FileSystemPlayerStore); ok {
+		logStartupReports(fileStore)
+	}
+
+	games, closeGames, err := poker.FileSystemGameStoreFromFile(*gamesFile, options...)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeGames()
+
+	game := poker.NewTexasHoldem(poker.BlindAlerterFunc(poker.Alerter), store, games)
 	cli := poker.NewCLI(os.Stdin, os.Stdout, game)
 
 	cli.PlayPoker()
 
 }
+
+func logStartupReports(store *poker.FileSystemPlayerStore) {
+	if recovery := store.Recovered(); recovery != nil {
+		log.Println(recovery)
+	}
+
+	if migration := store.Migrated(); migration != nil {
+		log.Println(migration)
+	}
+}
+
+func dbPath() string {
+	if *dbFile != "" {
+		return *dbFile
+	}
+	return poker.DefaultDBFile(*storeBackend)
+}
+
+// storeOptions encrypts the stores when there's a key in -key-file or the
+// environment.
+func storeOptions() ([]poker.FileSystemOption, error) {
+	key, err := poker.LoadKey(*keyFile)
+
+	if err != nil || key == nil {
+		return nil, err
+	}
+
+	return []poker.FileSystemOption{poker.WithEncryptionKey(key)}, nil
+}
@@ -1,31 +1,161 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	poker "github.com/ljones140/golang-player-webserver"
 )
 
-const dbFileName = "game.db.json"
+var (
+	storeBackend = flag.String("store", poker.JSONBackend, "player store to use: json, eventlog or sqlite, json rewrites the whole file on every write so use eventlog or sqlite for large leagues")
+	dbFile       = flag.String("db", "", "database file, defaults to game.db.json, game.db.log or game.db.sqlite for the chosen store")
+	gamesFile    = flag.String("games", "game.history.json", "game history file")
+	tieBreaks    = flag.String("tiebreak", "", "comma separated tie-breaks for the league: fewest-games, recent-win or name")
+	adminToken   = flag.String("admin-token", os.Getenv("POKER_ADMIN_TOKEN"), "bearer token for the /admin endpoints, which are off without one, defaults to $POKER_ADMIN_TOKEN")
+	keepSnaps    = flag.Int("keep-snapshots", 10, "number of snapshots of the json store to keep, 0 keeps them all")
+	watch        = flag.Duration("watch", 2*time.Second, "how often to check the json store's file for outside changes, 0 turns it off")
+	writeBehind  = flag.Duration("write-behind", 0, "save wins to the json store in batches this often instead of one by one, 0 turns it off")
+	maxPending   = flag.Int("write-behind-max", 100, "save wins written behind as soon as this many are waiting")
+	keyFile      = flag.String("key-file", "", "file holding the key the databases are encrypted with, defaults to $"+poker.DBKeyEnv)
+	cacheLeague  = flag.Duration("cache-league", 0, "serve the league from memory for up to this long between wins, 0 turns it off")
+	storeMetrics = flag.Bool("store-metrics", false, "count and time player store calls, served at /admin/metrics")
+	logStore     = flag.String("log-store", "", "log player store calls as JSON at this level: debug, info or error, empty turns it off")
+)
 
 func main() {
-	store, close, err := poker.FileSystemPlayerStoreFromFile(dbFileName)
+	flag.Parse()
+
+	rankings, err := poker.ParseTieBreaks(*tieBreaks)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	key, err := poker.LoadKey(*keyFile)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var options []poker.FileSystemOption
+
+	if key != nil {
+		options = append(options, poker.WithEncryptionKey(key))
+	}
+
+	store, close, err := poker.OpenPlayerStore(*storeBackend, *dbFile, options...)
 
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer close()
 
-	game := poker.NewTexasHoldem(poker.BlindAlerterFunc(poker.Alerter), store)
+	if fileStore, ok := store.(*poker.FileSystemPlayerStore); ok {
+		logStartupReports(fileStore)
+		fileStore.KeepSnapshots = *keepSnaps
+
+		if *watch > 0 {
+			stopWatching, err := fileStore.Watch(*watch, func(err error) { log.Println(err) })
+
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer stopWatching()
+		}
+
+		if *writeBehind > 0 {
+			stopWritingBehind, err := fileStore.WriteBehind(*writeBehind, *maxPending, func(err error) { log.Println(err) })
+
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer func() {
+				if err := stopWritingBehind(); err != nil {
+					log.Println(err)
+				}
+			}()
+		}
+	}
+
+	if store, err = decorateStore(store); err != nil {
+		log.Fatal(err)
+	}
+
+	games, closeGames, err := poker.FileSystemGameStoreFromFile(*gamesFile, options...)
 
-	server, err := poker.NewPlayerServer(store, game)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeGames()
+
+	game := poker.NewTexasHoldem(poker.BlindAlerterFunc(poker.Alerter), store, games)
+
+	server, err := poker.NewPlayerServer(store, games, game)
 
 	if err != nil {
 		log.Fatalf("problem creating player server %v", err)
 	}
 
-	if err := http.ListenAndServe(":5000", server); err != nil {
+	server.TieBreaks = rankings
+	server.AdminToken = *adminToken
+
+	httpServer := &http.Server{Addr: ":5000", Handler: server}
+
+	// shut down cleanly on a signal so the deferred flushes and closes run
+	signals, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	go func() {
+		<-signals.Done()
+		httpServer.Shutdown(context.Background())
+	}()
+
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("could not listn on port 5000 %v", err)
 	}
 }
+
+func logStartupReports(store *poker.FileSystemPlayerStore) {
+	if recovery := store.Recovered(); recovery != nil {
+		log.Println(recovery)
+	}
+
+	if migration := store.Migrated(); migration != nil {
+		log.Println(migration)
+	}
+}
+
+// decorateStore wraps store in the cache, metrics and logging asked for. The
+// metrics and logs see every call the server makes, cached or not.
+func decorateStore(store poker.PlayerStore) (poker.PlayerStore, error) {
+	if *cacheLeague > 0 {
+		store = poker.NewCachedPlayerStore(store, *cacheLeague)
+	}
+
+	if *storeMetrics {
+		store = poker.NewMetricsPlayerStore(store)
+	}
+
+	if *logStore != "" {
+		var level slog.Level
+
+		if err := level.UnmarshalText([]byte(*logStore)); err != nil {
+			return nil, fmt.Errorf("bad -log-store level %q, %v", *logStore, err)
+		}
+
+		logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+		store = poker.NewLoggingPlayerStore(store, logger)
+	}
+
+	return store, nil
+}
@@ -0,0 +1,285 @@
+package poker
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrEncrypted    = errors.New("file is encrypted, a key is needed to read it")
+	ErrNotEncrypted = errors.New("file is not encrypted")
+	ErrInvalidKey   = errors.New("invalid key")
+)
+
+// DBKeyEnv is the environment variable LoadKey reads the key from when it
+// isn't given a key file.
+const DBKeyEnv = "POKER_DB_KEY"
+
+// encryptedHeader starts every encrypted file, it's followed by the nonce and
+// the sealed contents.
+const encryptedHeader = "poker-aes-256-gcm-v1\n"
+
+// KeySize is the size of an encryption key, in bytes.
+const KeySize = 32
+
+// FileSystemOption configures a file system store as it's opened.
+type FileSystemOption func(*fileOptions) error
+
+type fileOptions struct {
+	cipher *fileCipher
+}
+
+// WithEncryptionKey keeps the store's file, its backup and its snapshots
+// encrypted with AES-256-GCM under key, which must be KeySize bytes.
+func WithEncryptionKey(key []byte) FileSystemOption {
+	return func(o *fileOptions) error {
+		c, err := newFileCipher(key)
+		o.cipher = c
+		return err
+	}
+}
+
+func applyFileOptions(options []FileSystemOption) (fileOptions, error) {
+	var o fileOptions
+
+	for _, option := range options {
+		if err := option(&o); err != nil {
+			return fileOptions{}, err
+		}
+	}
+
+	return o, nil
+}
+
+// LoadKey reads a base64 encoded key from keyFile or, without one, from the
+// DBKeyEnv environment variable. It returns a nil key when neither is set.
+func LoadKey(keyFile string) ([]byte, error) {
+	encoded := os.Getenv(DBKeyEnv)
+
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("problem reading key file %s, %v", keyFile, err)
+		}
+
+		encoded = string(data)
+	}
+
+	if encoded == "" {
+		return nil, nil
+	}
+
+	return ParseKey(encoded)
+}
+
+// ParseKey decodes a base64 encoded key.
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("%w: want %d bytes encoded as base64", ErrInvalidKey, KeySize)
+	}
+
+	return key, nil
+}
+
+// GenerateKey returns a new random key, base64 encoded.
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("problem generating key, %v", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// fileCipher seals and opens the contents of a store's files. A nil
+// fileCipher leaves them in plain text.
+type fileCipher struct {
+	aead cipher.AEAD
+}
+
+func newFileCipher(key []byte) (*fileCipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("%w: want %d bytes, got %d", ErrInvalidKey, KeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	return &fileCipher{aead: aead}, nil
+}
+
+func (c *fileCipher) seal(plain []byte) ([]byte, error) {
+	if c == nil {
+		return plain, nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("problem generating nonce, %v", err)
+	}
+
+	sealed := append([]byte(encryptedHeader), nonce...)
+	return c.aead.Seal(sealed, nonce, plain, []byte(encryptedHeader)), nil
+}
+
+func (c *fileCipher) open(data []byte) ([]byte, error) {
+	encrypted := bytes.HasPrefix(data, []byte(encryptedHeader))
+
+	switch {
+	case c == nil && encrypted:
+		return nil, ErrEncrypted
+	case c == nil || len(data) == 0:
+		return data, nil
+	case !encrypted:
+		return nil, ErrNotEncrypted
+	}
+
+	sealed := data[len(encryptedHeader):]
+
+	if len(sealed) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("%w: it's too short", ErrInvalidKey)
+	}
+
+	nonce, sealed := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, sealed, []byte(encryptedHeader))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: it can't be decrypted with this key or has been tampered with", ErrInvalidKey)
+	}
+
+	return plain, nil
+}
+
+// sealingWriter seals each write before passing it on. A json.Encoder makes a
+// single write per value, so each value the stores encode is sealed whole.
+type sealingWriter struct {
+	w      io.Writer
+	cipher *fileCipher
+}
+
+func (s sealingWriter) Write(p []byte) (int, error) {
+	sealed, err := s.cipher.seal(p)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := s.w.Write(sealed); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// RekeyFile re-encrypts the store file at path, along with its backup and
+// snapshots, from oldKey to newKey. A nil oldKey encrypts plain files and a
+// nil newKey decrypts them. Every file is read before any is written, so a
+// wrong old key changes nothing. Stop anything using the store first.
+func RekeyFile(path string, oldKey, newKey []byte) error {
+	return RekeyFiles(oldKey, newKey, path)
+}
+
+// RekeyFiles re-encrypts the store files at paths as RekeyFile does, reading
+// every file of every store before writing any, so stores that share a key
+// are either all rekeyed or all left as they were.
+func RekeyFiles(oldKey, newKey []byte, paths ...string) error {
+	from, err := optionalCipher(oldKey)
+
+	if err != nil {
+		return err
+	}
+
+	to, err := optionalCipher(newKey)
+
+	if err != nil {
+		return err
+	}
+
+	var files []string
+
+	for _, path := range paths {
+		lock, err := openFileLock(path)
+
+		if err != nil {
+			return err
+		}
+		defer lock.Close()
+
+		if err := lock.Lock(); err != nil {
+			return err
+		}
+		defer lock.Unlock()
+
+		snapshots, _ := filepath.Glob(filepath.Join(path+snapshotsSuffix, "*"+snapshotExt))
+		files = append(files, path, path+backupSuffix)
+		files = append(files, snapshots...)
+	}
+
+	plain := map[string][]byte{}
+
+	for _, p := range files {
+		data, err := os.ReadFile(p)
+
+		if errors.Is(err, os.ErrNotExist) || len(data) == 0 {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("problem reading %s, %v", p, err)
+		}
+
+		if plain[p], err = from.open(data); err != nil {
+			return fmt.Errorf("problem decrypting %s, %w", p, err)
+		}
+	}
+
+	for _, p := range files {
+		data, ok := plain[p]
+
+		if !ok {
+			continue
+		}
+
+		sealed, err := to.seal(data)
+
+		if err != nil {
+			return err
+		}
+
+		if err := writeFileAtomically(p, sealed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func optionalCipher(key []byte) (*fileCipher, error) {
+	if key == nil {
+		return nil, nil
+	}
+	return newFileCipher(key)
+}
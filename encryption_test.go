@@ -0,0 +1,262 @@
+package poker_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func TestEncryptedPlayerStore(t *testing.T) {
+	t.Run("keeps wins across reopening with the same key", func(t *testing.T) {
+		key := mustGenerateKey(t)
+		store, path := mustMakeEncryptedStore(t, key)
+
+		poker.MustRecordWin(t, store, "Chris")
+
+		reopened := mustOpenEncryptedStore(t, path, key)
+		poker.AssertScore(t, reopened, "Chris", 1)
+	})
+
+	t.Run("doesn't keep the players in plain text", func(t *testing.T) {
+		store, path := mustMakeEncryptedStore(t, mustGenerateKey(t))
+
+		poker.MustRecordWin(t, store, "Chris")
+		poker.MustRecordWin(t, store, "Cleo")
+
+		assertNotInFile(t, path, "Chris")
+		assertNotInFile(t, path+".bak", "Chris")
+	})
+
+	t.Run("won't open with the wrong key or none", func(t *testing.T) {
+		store, path := mustMakeEncryptedStore(t, mustGenerateKey(t))
+		poker.MustRecordWin(t, store, "Chris")
+
+		_, _, err := poker.FileSystemPlayerStoreFromFile(path, poker.WithEncryptionKey(mustGenerateKey(t)))
+		assertErrorIs(t, err, poker.ErrInvalidKey)
+
+		_, _, err = poker.FileSystemPlayerStoreFromFile(path)
+		assertErrorIs(t, err, poker.ErrEncrypted)
+	})
+
+	t.Run("won't read a plain file as encrypted", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `[{"Name": "Chris", "Wins": 10}]`)
+		defer cleanDatabase()
+
+		_, _, err := poker.FileSystemPlayerStoreFromFile(database.Name(), poker.WithEncryptionKey(mustGenerateKey(t)))
+		assertErrorIs(t, err, poker.ErrNotEncrypted)
+	})
+
+	t.Run("rejects a key of the wrong size", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+
+		_, _, err := poker.FileSystemPlayerStoreFromFile(database.Name(), poker.WithEncryptionKey([]byte("too short")))
+		assertErrorIs(t, err, poker.ErrInvalidKey)
+	})
+
+	t.Run("encrypts and restores snapshots", func(t *testing.T) {
+		store, path := mustMakeEncryptedStore(t, mustGenerateKey(t))
+		poker.MustRecordWin(t, store, "Chris")
+
+		snapshot, err := store.TakeSnapshot()
+		poker.AssertNoError(t, err)
+		assertNotInFile(t, snapshot.Path, "Chris")
+
+		poker.MustRecordWin(t, store, "Chris")
+		poker.AssertNoError(t, store.Restore(snapshot.Name))
+		poker.AssertScore(t, store, "Chris", 1)
+
+		assertNotInFile(t, path, "Chris")
+	})
+}
+
+func TestRekeyFile(t *testing.T) {
+	t.Run("encrypts a plain database, its backup and snapshots", func(t *testing.T) {
+		store, path := mustMakeSharedStore(t)
+		poker.MustRecordWin(t, store, "Chris")
+		poker.MustRecordWin(t, store, "Chris")
+		snapshot, err := store.TakeSnapshot()
+		poker.AssertNoError(t, err)
+
+		key := mustGenerateKey(t)
+		poker.AssertNoError(t, poker.RekeyFile(path, nil, key))
+
+		for _, file := range []string{path, path + ".bak", snapshot.Path} {
+			assertNotInFile(t, file, "Chris")
+		}
+
+		poker.AssertScore(t, mustOpenEncryptedStore(t, path, key), "Chris", 2)
+	})
+
+	t.Run("rotates the key", func(t *testing.T) {
+		oldKey, newKey := mustGenerateKey(t), mustGenerateKey(t)
+		store, path := mustMakeEncryptedStore(t, oldKey)
+		poker.MustRecordWin(t, store, "Chris")
+
+		poker.AssertNoError(t, poker.RekeyFile(path, oldKey, newKey))
+
+		_, _, err := poker.FileSystemPlayerStoreFromFile(path, poker.WithEncryptionKey(oldKey))
+		assertErrorIs(t, err, poker.ErrInvalidKey)
+
+		poker.AssertScore(t, mustOpenEncryptedStore(t, path, newKey), "Chris", 1)
+	})
+
+	t.Run("changes nothing given the wrong old key", func(t *testing.T) {
+		key := mustGenerateKey(t)
+		store, path := mustMakeEncryptedStore(t, key)
+		poker.MustRecordWin(t, store, "Chris")
+
+		err := poker.RekeyFile(path, mustGenerateKey(t), mustGenerateKey(t))
+		assertErrorIs(t, err, poker.ErrInvalidKey)
+
+		poker.AssertScore(t, mustOpenEncryptedStore(t, path, key), "Chris", 1)
+	})
+}
+
+func TestRekeyFiles(t *testing.T) {
+	t.Run("changes neither store when the old key opens only one", func(t *testing.T) {
+		key := mustGenerateKey(t)
+		plain, plainPath := mustMakeSharedStore(t)
+		poker.MustRecordWin(t, plain, "Cleo")
+		store, path := mustMakeEncryptedStore(t, key)
+		poker.MustRecordWin(t, store, "Chris")
+
+		err := poker.RekeyFiles(key, mustGenerateKey(t), path, plainPath)
+		assertErrorIs(t, err, poker.ErrNotEncrypted)
+
+		poker.AssertScore(t, mustOpenEncryptedStore(t, path, key), "Chris", 1)
+		assertScoreOnDisk(t, plainPath, "Cleo", 1)
+	})
+}
+
+func TestEncryptedGameStore(t *testing.T) {
+	key := mustGenerateKey(t)
+	database, cleanDatabase := createTempFile(t, "")
+	defer cleanDatabase()
+
+	games, closeGames, err := poker.FileSystemGameStoreFromFile(database.Name(), poker.WithEncryptionKey(key))
+	poker.AssertNoError(t, err)
+	_, err = games.RecordGame(poker.GameRecord{NumberOfPlayers: 2, Players: []string{"Chris", "Cleo"}, Winner: "Chris"})
+	poker.AssertNoError(t, err)
+	closeGames()
+
+	assertNotInFile(t, database.Name(), "Chris")
+
+	reopened, closeReopened, err := poker.FileSystemGameStoreFromFile(database.Name(), poker.WithEncryptionKey(key))
+	poker.AssertNoError(t, err)
+	defer closeReopened()
+
+	got, err := reopened.GetGames()
+	poker.AssertNoError(t, err)
+
+	if len(got) != 1 || got[0].Winner != "Chris" {
+		t.Errorf("got games %v, want Chris's win", got)
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	key, err := poker.GenerateKey()
+	poker.AssertNoError(t, err)
+
+	t.Run("reads the key file", func(t *testing.T) {
+		keyFile := filepath.Join(t.TempDir(), "key")
+		poker.AssertNoError(t, os.WriteFile(keyFile, []byte(key+"\n"), 0600))
+		t.Setenv(poker.DBKeyEnv, "")
+
+		got, err := poker.LoadKey(keyFile)
+		poker.AssertNoError(t, err)
+
+		if len(got) != poker.KeySize {
+			t.Errorf("got a %d byte key, want %d", len(got), poker.KeySize)
+		}
+	})
+
+	t.Run("falls back to the environment", func(t *testing.T) {
+		t.Setenv(poker.DBKeyEnv, key)
+
+		got, err := poker.LoadKey("")
+		poker.AssertNoError(t, err)
+
+		if len(got) != poker.KeySize {
+			t.Errorf("got a %d byte key, want %d", len(got), poker.KeySize)
+		}
+	})
+
+	t.Run("returns no key when there isn't one", func(t *testing.T) {
+		t.Setenv(poker.DBKeyEnv, "")
+
+		got, err := poker.LoadKey("")
+		poker.AssertNoError(t, err)
+
+		if got != nil {
+			t.Errorf("got key %v, want none", got)
+		}
+	})
+
+	t.Run("rejects a badly encoded key", func(t *testing.T) {
+		t.Setenv(poker.DBKeyEnv, "not a key")
+
+		_, err := poker.LoadKey("")
+		assertErrorIs(t, err, poker.ErrInvalidKey)
+	})
+}
+
+func mustGenerateKey(t testing.TB) []byte {
+	t.Helper()
+
+	encoded, err := poker.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := poker.ParseKey(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func mustMakeEncryptedStore(t *testing.T, key []byte) (*poker.FileSystemPlayerStore, string) {
+	t.Helper()
+
+	database, cleanDatabase := createTempFile(t, "")
+	t.Cleanup(cleanDatabase)
+
+	return mustOpenEncryptedStore(t, database.Name(), key), database.Name()
+}
+
+func mustOpenEncryptedStore(t *testing.T, path string, key []byte) *poker.FileSystemPlayerStore {
+	t.Helper()
+
+	store, closeStore, err := poker.FileSystemPlayerStoreFromFile(path, poker.WithEncryptionKey(key))
+	poker.AssertNoError(t, err)
+	t.Cleanup(closeStore)
+
+	return store
+}
+
+func assertNotInFile(t testing.TB, path, plain string) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read %s, %v", path, err)
+	}
+
+	if bytes.Contains(data, []byte(plain)) {
+		t.Errorf("found %q in %s in plain text", plain, path)
+	}
+}
+
+func assertErrorIs(t testing.TB, got, want error) {
+	t.Helper()
+
+	if !errors.Is(got, want) {
+		t.Errorf("got error %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,383 @@
+package poker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const defaultCompactAfter = 1000
+
+// EventLogPlayerStore appends one win event per line to a log file instead
+// of rewriting the whole league, and rebuilds the league on startup by
+// replaying the log on top of the last snapshot. The league is held in a
+// leagueIndex, so it suits leagues of many thousands of players.
+//
+// A store opened with EventLogPlayerStoreFromFile locks the log while it
+// reads or writes, and catches up on the wins other processes have logged
+// first, so they can share the file.
+type EventLogPlayerStore struct {
+	mu           sync.RWMutex
+	log          *os.File
+	lock         *fileLock
+	snapshotPath string
+	league       *leagueIndex
+	seq          int
+	logged       int
+
+	// offset is how far into the log the store has read, and snapshotSeen
+	// is the version of the snapshot it last wrote or loaded.
+	offset       int64
+	snapshotSeen os.FileInfo
+
+	// CompactAfter is the number of logged events after which the league
+	// is written to the snapshot and the log is truncated.
+	CompactAfter int
+}
+
+type winEvent struct {
+	Seq  int    `json:"seq"`
+	Name string `json:"name"`
+}
+
+type eventLogSnapshot struct {
+	Seq    int    `json:"seq"`
+	League League `json:"league"`
+}
+
+func EventLogPlayerStoreFromFile(path string) (*EventLogPlayerStore, func(), error) {
+	log, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("problem opening %s %v", path, err)
+	}
+
+	lock, err := openFileLock(path)
+
+	if err != nil {
+		log.Close()
+		return nil, nil, err
+	}
+
+	if err := lock.Lock(); err != nil {
+		log.Close()
+		lock.Close()
+		return nil, nil, err
+	}
+
+	store, err := NewEventLogPlayerStore(log)
+	lock.Unlock()
+
+	if err != nil {
+		log.Close()
+		lock.Close()
+		return nil, nil, fmt.Errorf("problem creating event log player store, %v ", err)
+	}
+
+	store.lock = lock
+
+	closeFunc := func() {
+		log.Close()
+		lock.Close()
+	}
+
+	return store, closeFunc, nil
+}
+
+func NewEventLogPlayerStore(log *os.File) (*EventLogPlayerStore, error) {
+	store := &EventLogPlayerStore{
+		log:          log,
+		snapshotPath: log.Name() + ".snapshot",
+		league:       newLeagueIndex(nil),
+		CompactAfter: defaultCompactAfter,
+	}
+
+	if err := store.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	if err := store.replay(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+func (e *EventLogPlayerStore) GetLeague() (League, error) {
+	var league League
+	err := e.read(func() { league = e.league.league() })
+
+	return league, err
+}
+
+func (e *EventLogPlayerStore) GetPlayerScore(name string) (int, error) {
+	wins := 0
+	err := e.read(func() {
+		if player := e.league.find(name); player != nil {
+			wins = player.Wins
+		}
+	})
+
+	return wins, err
+}
+
+// read runs view against the league. A store sharing its log with other
+// processes catches up on their wins first, under the lock, so it never
+// serves standings older than the log.
+func (e *EventLogPlayerStore) read(view func()) error {
+	if e.lock == nil {
+		e.mu.RLock()
+		defer e.mu.RUnlock()
+
+		view()
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	unlock, err := e.lockLog()
+
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	view()
+	return nil
+}
+
+func (e *EventLogPlayerStore) RecordWin(name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	unlock, err := e.lockLog()
+
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	event := winEvent{Seq: e.seq + 1, Name: name}
+
+	if err := e.append(event); err != nil {
+		return err
+	}
+
+	e.apply(event)
+	e.logged++
+
+	if e.CompactAfter > 0 && e.logged >= e.CompactAfter {
+		// the win is already durable in the log, a failed compaction is
+		// retried on the next win rather than reported as a failed win
+		e.compact()
+	}
+
+	return nil
+}
+
+// Compact writes the current league to the snapshot file and truncates the
+// log. Events carry a sequence number, so a crash between the two steps
+// cannot count a win twice.
+func (e *EventLogPlayerStore) Compact() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	unlock, err := e.lockLog()
+
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return e.compact()
+}
+
+// lockLog locks the log against other processes, if the store has a lock,
+// and catches up on what they've written to it since the store last read it.
+func (e *EventLogPlayerStore) lockLog() (unlock func(), err error) {
+	if e.lock == nil {
+		return func() {}, nil
+	}
+
+	if err := e.lock.Lock(); err != nil {
+		return nil, err
+	}
+
+	if err := e.catchUp(); err != nil {
+		e.lock.Unlock()
+		return nil, err
+	}
+
+	return func() { e.lock.Unlock() }, nil
+}
+
+// catchUp reads the events appended to the log since the store last read
+// it. When another process has compacted the log its snapshot is loaded
+// first, as the events it folded in are gone from the log.
+func (e *EventLogPlayerStore) catchUp() error {
+	info, err := os.Stat(e.snapshotPath)
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("problem checking snapshot %s, %v", e.snapshotPath, err)
+	}
+
+	if info != nil && (e.snapshotSeen == nil || !sameFileVersion(e.snapshotSeen, info)) {
+		if err := e.loadSnapshot(); err != nil {
+			return err
+		}
+
+		e.offset = 0
+		e.logged = 0
+	}
+
+	return e.replay()
+}
+
+func (e *EventLogPlayerStore) compact() error {
+	snapshot, err := json.Marshal(eventLogSnapshot{Seq: e.seq, League: e.league.league()})
+
+	if err != nil {
+		return fmt.Errorf("problem encoding snapshot, %v", err)
+	}
+
+	if err := writeFileAtomically(e.snapshotPath, snapshot); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(e.snapshotPath); err == nil {
+		e.snapshotSeen = info
+	}
+
+	if err := e.log.Truncate(0); err != nil {
+		return fmt.Errorf("problem truncating event log %s, %v", e.log.Name(), err)
+	}
+
+	if _, err := e.log.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("problem rewinding event log %s, %v", e.log.Name(), err)
+	}
+
+	e.offset = 0
+	e.logged = 0
+	return nil
+}
+
+func (e *EventLogPlayerStore) append(event winEvent) error {
+	line, err := json.Marshal(event)
+
+	if err != nil {
+		return fmt.Errorf("problem encoding win event, %v", err)
+	}
+
+	end, err := e.log.Seek(0, io.SeekEnd)
+
+	if err != nil {
+		return fmt.Errorf("problem seeking to end of event log %s, %v", e.log.Name(), err)
+	}
+
+	if _, err := e.log.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("problem appending to event log %s, %v", e.log.Name(), err)
+	}
+
+	if err := e.log.Sync(); err != nil {
+		return fmt.Errorf("problem syncing event log %s, %v", e.log.Name(), err)
+	}
+
+	e.offset = end + int64(len(line)) + 1
+	return nil
+}
+
+func (e *EventLogPlayerStore) apply(event winEvent) {
+	e.league.add(event.Name, 1)
+	e.seq = event.Seq
+}
+
+func (e *EventLogPlayerStore) loadSnapshot() error {
+	data, err := os.ReadFile(e.snapshotPath)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("problem reading snapshot %s, %v", e.snapshotPath, err)
+	}
+
+	var snapshot eventLogSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("problem parsing snapshot %s, %v", e.snapshotPath, err)
+	}
+
+	if info, err := os.Stat(e.snapshotPath); err == nil {
+		e.snapshotSeen = info
+	}
+
+	e.league = newLeagueIndex(snapshot.League)
+	e.seq = snapshot.Seq
+	return nil
+}
+
+// replay applies the events in the log from where the store last read up to.
+func (e *EventLogPlayerStore) replay() error {
+	if info, err := e.log.Stat(); err == nil && info.Size() < e.offset {
+		e.offset = 0
+	}
+
+	if _, err := e.log.Seek(e.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("problem rewinding event log %s, %v", e.log.Name(), err)
+	}
+
+	reader := bufio.NewReader(e.log)
+	offset := e.offset
+
+	for {
+		line, err := reader.ReadBytes('\n')
+
+		if err == io.EOF && len(line) == 0 {
+			return nil
+		}
+
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("problem reading event log %s, %v", e.log.Name(), err)
+		}
+
+		var event winEvent
+		decodeErr := json.Unmarshal(bytes.TrimSpace(line), &event)
+
+		if err == io.EOF || (decodeErr != nil && isLastLine(reader)) {
+			// a crash mid-append leaves a partial last line, drop it
+			return e.dropTail(offset)
+		}
+
+		if decodeErr != nil {
+			return fmt.Errorf("problem parsing event log %s at offset %d, %v", e.log.Name(), offset, decodeErr)
+		}
+
+		offset += int64(len(line))
+		e.offset = offset
+
+		if event.Seq <= e.seq {
+			continue
+		}
+
+		e.apply(event)
+		e.logged++
+	}
+}
+
+func isLastLine(reader *bufio.Reader) bool {
+	_, err := reader.Peek(1)
+	return err == io.EOF
+}
+
+func (e *EventLogPlayerStore) dropTail(offset int64) error {
+	if err := e.log.Truncate(offset); err != nil {
+		return fmt.Errorf("problem truncating partial event in %s, %v", e.log.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package poker_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func TestEventLogPlayerStore(t *testing.T) {
+	t.Run("replays recorded wins when reopened", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+
+		store, err := poker.NewEventLogPlayerStore(database)
+		poker.AssertNoError(t, err)
+
+		poker.MustRecordWin(t, store, "Chris")
+		poker.MustRecordWin(t, store, "Cleo")
+		poker.MustRecordWin(t, store, "Chris")
+
+		reopened, err := poker.NewEventLogPlayerStore(database)
+		poker.AssertNoError(t, err)
+
+		want := []poker.Player{
+			{"Chris", 2},
+			{"Cleo", 1},
+		}
+		poker.AssertLeague(t, poker.MustGetLeague(t, reopened), want)
+	})
+
+	t.Run("appends one line per win", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+
+		store, err := poker.NewEventLogPlayerStore(database)
+		poker.AssertNoError(t, err)
+
+		poker.MustRecordWin(t, store, "Chris")
+		poker.MustRecordWin(t, store, "Chris")
+
+		got := readFile(t, database.Name())
+		want := "{\"seq\":1,\"name\":\"Chris\"}\n{\"seq\":2,\"name\":\"Chris\"}\n"
+
+		if got != want {
+			t.Errorf("got log %q want %q", got, want)
+		}
+	})
+
+	t.Run("compacts into a snapshot when the log grows", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+
+		store, err := poker.NewEventLogPlayerStore(database)
+		poker.AssertNoError(t, err)
+		store.CompactAfter = 2
+
+		poker.MustRecordWin(t, store, "Chris")
+		poker.MustRecordWin(t, store, "Cleo")
+		poker.MustRecordWin(t, store, "Chris")
+
+		got := readFile(t, database.Name())
+		want := "{\"seq\":3,\"name\":\"Chris\"}\n"
+
+		if got != want {
+			t.Errorf("got log %q want %q", got, want)
+		}
+
+		reopened, err := poker.NewEventLogPlayerStore(database)
+		poker.AssertNoError(t, err)
+		poker.AssertScore(t, reopened, "Chris", 2)
+		poker.AssertScore(t, reopened, "Cleo", 1)
+	})
+
+	t.Run("does not replay events already in the snapshot", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `{"seq":1,"name":"Chris"}
+{"seq":2,"name":"Chris"}
+{"seq":3,"name":"Chris"}
+`)
+		defer cleanDatabase()
+		writeFile(t, database.Name()+".snapshot", `{"seq":2,"league":[{"Name":"Chris","Wins":2}]}`)
+
+		store, err := poker.NewEventLogPlayerStore(database)
+		poker.AssertNoError(t, err)
+
+		poker.AssertScore(t, store, "Chris", 3)
+	})
+
+	t.Run("drops a partially written last event", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `{"seq":1,"name":"Chris"}
+{"seq":2,"na`)
+		defer cleanDatabase()
+
+		store, err := poker.NewEventLogPlayerStore(database)
+		poker.AssertNoError(t, err)
+		poker.AssertScore(t, store, "Chris", 1)
+
+		poker.MustRecordWin(t, store, "Cleo")
+
+		reopened, err := poker.NewEventLogPlayerStore(database)
+		poker.AssertNoError(t, err)
+		poker.AssertScore(t, reopened, "Chris", 1)
+		poker.AssertScore(t, reopened, "Cleo", 1)
+	})
+
+	t.Run("keeps the league in order as wins are recorded", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+		writeFile(t, database.Name()+".snapshot", `{"seq":0,"league":[{"Name":"Ruth","Wins":2},{"Name":"Chris","Wins":3},{"Name":"Cleo","Wins":2}]}`)
+
+		store, err := poker.NewEventLogPlayerStore(database)
+		poker.AssertNoError(t, err)
+
+		poker.AssertLeague(t, poker.MustGetLeague(t, store), []poker.Player{{"Chris", 3}, {"Cleo", 2}, {"Ruth", 2}})
+
+		poker.MustRecordWin(t, store, "ruth")
+		poker.MustRecordWin(t, store, "Tom")
+
+		poker.AssertLeague(t, poker.MustGetLeague(t, store), []poker.Player{{"Chris", 3}, {"Ruth", 3}, {"Cleo", 2}, {"Tom", 1}})
+
+		poker.MustRecordWin(t, store, "Ruth")
+		poker.MustRecordWin(t, store, "Tom")
+
+		poker.AssertLeague(t, poker.MustGetLeague(t, store), []poker.Player{{"Ruth", 4}, {"Chris", 3}, {"Cleo", 2}, {"Tom", 2}})
+		poker.AssertScore(t, store, "RUTH", 4)
+	})
+
+	t.Run("errors on a corrupt event in the middle of the log", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `{"seq":1,"name":"Chris"}
+not json
+{"seq":3,"name":"Chris"}
+`)
+		defer cleanDatabase()
+
+		_, err := poker.NewEventLogPlayerStore(database)
+
+		if err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+}
+
+func TestEventLogStoresSharingAFile(t *testing.T) {
+	t.Run("does not lose concurrent wins across compactions", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+
+		first, closeFirst, err := poker.EventLogPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeFirst()
+
+		second, closeSecond, err := poker.EventLogPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeSecond()
+
+		winsEach := 20
+		var wg sync.WaitGroup
+		for _, store := range []*poker.EventLogPlayerStore{first, second} {
+			store.CompactAfter = 7
+			wg.Add(1)
+			go func(store *poker.EventLogPlayerStore) {
+				defer wg.Done()
+				for i := 0; i < winsEach; i++ {
+					if err := store.RecordWin("Chris"); err != nil {
+						t.Error(err)
+					}
+				}
+			}(store)
+		}
+		wg.Wait()
+
+		reopened, closeReopened, err := poker.EventLogPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeReopened()
+
+		poker.AssertScore(t, reopened, "Chris", 2*winsEach)
+	})
+
+	t.Run("reads the wins another process logged", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+
+		writer, closeWriter, err := poker.EventLogPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeWriter()
+
+		reader, closeReader, err := poker.EventLogPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeReader()
+
+		writer.CompactAfter = 2
+		for i := 0; i < 3; i++ {
+			poker.MustRecordWin(t, writer, "Chris")
+		}
+
+		poker.AssertScore(t, reader, "Chris", 3)
+		poker.AssertLeague(t, poker.MustGetLeague(t, reader), poker.League{{"Chris", 3}})
+	})
+}
+
+func readFile(t testing.TB, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		t.Fatalf("could not read %s %v", path, err)
+	}
+
+	return string(data)
+}
+
+func writeFile(t testing.TB, path, data string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(data), 0666); err != nil {
+		t.Fatalf("could not write %s %v", path, err)
+	}
+}
+
+const benchmarkPlayers = 100000
+
+// BenchmarkLargeLeague measures the stores with a league of benchmarkPlayers
+// players, where wins are spread so most players share their wins with
+// hundreds of others.
+func BenchmarkLargeLeague(b *testing.B) {
+	stores := []struct {
+		name string
+		open func(b *testing.B, league poker.League) poker.PlayerStore
+	}{
+		{poker.EventLogBackend, openLargeEventLogStore},
+		{poker.JSONBackend, openLargeFileSystemStore},
+	}
+
+	league := make(poker.League, benchmarkPlayers)
+	for i := range league {
+		league[i] = poker.Player{Name: fmt.Sprintf("Player %06d", i), Wins: 1 + i%200}
+	}
+
+	for _, s := range stores {
+		store := s.open(b, league)
+
+		b.Run(s.name+"/RecordWin", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				poker.MustRecordWin(b, store, league[i%len(league)].Name)
+			}
+		})
+
+		b.Run(s.name+"/GetPlayerScore", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := store.GetPlayerScore(league[i%len(league)].Name); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(s.name+"/GetLeague", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				poker.MustGetLeague(b, store)
+			}
+		})
+	}
+}
+
+func openLargeEventLogStore(b *testing.B, league poker.League) poker.PlayerStore {
+	b.Helper()
+
+	database, cleanDatabase := createTempFile(b, "")
+	b.Cleanup(cleanDatabase)
+
+	snapshot, err := json.Marshal(map[string]interface{}{"seq": 0, "league": league})
+	if err != nil {
+		b.Fatal(err)
+	}
+	writeFile(b, database.Name()+".snapshot", string(snapshot))
+
+	store, err := poker.NewEventLogPlayerStore(database)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return store
+}
+
+func openLargeFileSystemStore(b *testing.B, league poker.League) poker.PlayerStore {
+	b.Helper()
+
+	data, err := json.Marshal(league)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	database, cleanDatabase := createTempFile(b, string(data))
+	b.Cleanup(cleanDatabase)
+
+	store, err := poker.NewFileSystemPlayerStore(database)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return store
+}
@@ -0,0 +1,290 @@
+package poker
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownFormat   = errors.New("unknown format")
+	ErrInvalidImport   = errors.New("invalid import")
+	ErrUnknownConflict = errors.New("unknown conflict policy")
+)
+
+const (
+	CSVFormat  = "csv"
+	JSONFormat = "json"
+)
+
+// The ways an import can settle a player whose imported wins differ from the
+// wins they already have.
+const (
+	KeepExisting = "keep"
+	UseImported  = "replace"
+	AddImported  = "add"
+)
+
+// ImportStore is implemented by player stores that can merge a league of
+// results from elsewhere into their own.
+type ImportStore interface {
+	ImportLeague(league League, options ImportOptions) (ImportReport, error)
+}
+
+// ImportOptions say how to settle conflicts, one of KeepExisting, UseImported
+// or AddImported, and whether to only report what an import would change.
+// Reason is logged against every change the import makes.
+type ImportOptions struct {
+	Conflicts string
+	DryRun    bool
+	Reason    string
+}
+
+// ImportReport lists what an import changed, or in a dry run would change.
+type ImportReport struct {
+	Added     []Player
+	Unchanged int
+	Conflicts []ImportConflict
+	DryRun    bool
+}
+
+// ImportConflict is a player whose imported wins differ from the wins they
+// already had, and the wins they were left with.
+type ImportConflict struct {
+	Name     string
+	Existing int
+	Imported int
+	Result   int
+}
+
+func (r ImportReport) String() string {
+	var report strings.Builder
+
+	fmt.Fprintf(&report, "%d added, %d unchanged, %d conflicts", len(r.Added), r.Unchanged, len(r.Conflicts))
+
+	for _, player := range r.Added {
+		fmt.Fprintf(&report, "\n  added %s with %d wins", player.Name, player.Wins)
+	}
+
+	for _, conflict := range r.Conflicts {
+		fmt.Fprintf(&report, "\n  %s had %d wins, imported %d, now %d", conflict.Name, conflict.Existing, conflict.Imported, conflict.Result)
+	}
+
+	return report.String()
+}
+
+// ImportLeague merges league into the active season. Players the store
+// doesn't know are added, and names are resolved through aliases first.
+func (f *FileSystemPlayerStore) ImportLeague(league League, options ImportOptions) (ImportReport, error) {
+	if options.Conflicts == "" {
+		options.Conflicts = KeepExisting
+	}
+
+	if options.Conflicts != KeepExisting && options.Conflicts != UseImported && options.Conflicts != AddImported {
+		return ImportReport{}, fmt.Errorf("%w %q, want %s, %s or %s", ErrUnknownConflict, options.Conflicts, KeepExisting, UseImported, AddImported)
+	}
+
+	if strings.TrimSpace(options.Reason) == "" {
+		options.Reason = "import"
+	}
+
+	if options.DryRun {
+		f.mu.RLock()
+		db := f.db.clone()
+		db.Audit = slices.Clip(db.Audit)
+		f.mu.RUnlock()
+
+		report, err := db.importLeague(league, options)
+		report.DryRun = true
+		return report, err
+	}
+
+	var report ImportReport
+
+	err := f.update(func(db *playerDB) error {
+		var err error
+		report, err = db.importLeague(league, options)
+		return err
+	})
+
+	return report, err
+}
+
+func (db *playerDB) importLeague(league League, options ImportOptions) (ImportReport, error) {
+	report := ImportReport{Added: []Player{}, Conflicts: []ImportConflict{}}
+	season := db.activeSeason()
+	imported := map[string]bool{}
+	changed := League{}
+
+	for _, player := range league {
+		name := db.resolve(player.Name)
+
+		if name == "" || player.Wins < 0 {
+			return ImportReport{}, fmt.Errorf("%w: %q can't have %d wins", ErrInvalidImport, player.Name, player.Wins)
+		}
+
+		if imported[playerKey(name)] {
+			return ImportReport{}, fmt.Errorf("%w: %s is in the import more than once", ErrInvalidImport, name)
+		}
+		imported[playerKey(name)] = true
+
+		existing := season.wins(name)
+		wins := player.Wins
+
+		switch {
+		case existing == wins:
+			report.Unchanged++
+			continue
+		case existing == 0:
+			report.Added = append(report.Added, Player{name, wins})
+		case options.Conflicts == KeepExisting:
+			wins = existing
+		case options.Conflicts == AddImported:
+			wins = existing + player.Wins
+		}
+
+		if existing != 0 {
+			report.Conflicts = append(report.Conflicts, ImportConflict{Name: name, Existing: existing, Imported: player.Wins, Result: wins})
+		}
+
+		if wins != existing {
+			db.log(auditRecord{Action: setScoreAction, Player: name, Before: existing, After: wins, Reason: options.Reason})
+			changed = append(changed, Player{name, wins})
+		}
+	}
+
+	season.setAllWins(changed)
+
+	return report, nil
+}
+
+// ReadLeague reads a league in format, csv or json. A CSV league needs a
+// header row with name and wins columns, other columns are ignored.
+func ReadLeague(r io.Reader, format string) (League, error) {
+	switch format {
+	case JSONFormat:
+		return NewLeague(r)
+	case CSVFormat:
+		return readLeagueCSV(r)
+	default:
+		return nil, fmt.Errorf("%w %q, want %s or %s", ErrUnknownFormat, format, CSVFormat, JSONFormat)
+	}
+}
+
+func readLeagueCSV(r io.Reader) (League, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+
+	if err != nil {
+		return nil, fmt.Errorf("problem parsing league, %v", err)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidImport)
+	}
+
+	nameColumn, winsColumn := -1, -1
+	for i, heading := range rows[0] {
+		switch strings.ToLower(strings.TrimSpace(heading)) {
+		case "name":
+			nameColumn = i
+		case "wins":
+			winsColumn = i
+		}
+	}
+
+	if nameColumn < 0 || winsColumn < 0 {
+		return nil, fmt.Errorf("%w: the first row needs name and wins headings", ErrInvalidImport)
+	}
+
+	league := League{}
+
+	for line, row := range rows[1:] {
+		wins, err := strconv.Atoi(strings.TrimSpace(row[winsColumn]))
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d, bad wins %q", ErrInvalidImport, line+2, row[winsColumn])
+		}
+
+		league = append(league, Player{fromCSVText(row[nameColumn]), wins})
+	}
+
+	return league, nil
+}
+
+// WriteLeague writes league in format, csv or json. The CSV has a row per
+// player with their rank, name and wins, names a spreadsheet would take for a
+// formula are escaped with a leading quote.
+func WriteLeague(w io.Writer, league League, format string) error {
+	switch format {
+	case JSONFormat:
+		return json.NewEncoder(w).Encode(league)
+	case CSVFormat:
+		ranks := league.Ranks()
+		rows := [][]string{{"rank", "name", "wins"}}
+
+		for i, player := range league {
+			rows = append(rows, []string{strconv.Itoa(ranks[i]), csvText(player.Name), strconv.Itoa(player.Wins)})
+		}
+
+		return writeCSV(w, rows)
+	default:
+		return fmt.Errorf("%w %q, want %s or %s", ErrUnknownFormat, format, CSVFormat, JSONFormat)
+	}
+}
+
+// WriteGames writes the game history in format, csv or json. The CSV has a
+// row per game, with the players' names separated by semicolons.
+func WriteGames(w io.Writer, games []GameRecord, format string) error {
+	switch format {
+	case JSONFormat:
+		return json.NewEncoder(w).Encode(games)
+	case CSVFormat:
+		rows := [][]string{{"id", "started_at", "finished_at", "number_of_players", "players", "winner", "highest_blind"}}
+
+		for _, game := range games {
+			rows = append(rows, []string{
+				strconv.Itoa(game.ID),
+				game.StartedAt.Format(time.RFC3339),
+				game.FinishedAt.Format(time.RFC3339),
+				strconv.Itoa(game.NumberOfPlayers),
+				csvText(strings.Join(game.Players, ";")),
+				csvText(game.Winner),
+				strconv.Itoa(game.HighestBlind),
+			})
+		}
+
+		return writeCSV(w, rows)
+	default:
+		return fmt.Errorf("%w %q, want %s or %s", ErrUnknownFormat, format, CSVFormat, JSONFormat)
+	}
+}
+
+// csvText escapes text that a spreadsheet would run as a formula, starting
+// with =, +, -, @, a tab or a carriage return, by quoting it with a leading '.
+func csvText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// fromCSVText undoes csvText, so a league reads back as it was written.
+func fromCSVText(text string) string {
+	if unquoted, ok := strings.CutPrefix(text, "'"); ok && csvText(unquoted) != unquoted {
+		return unquoted
+	}
+	return text
+}
+
+func writeCSV(w io.Writer, rows [][]string) error {
+	if err := csv.NewWriter(w).WriteAll(rows); err != nil {
+		return fmt.Errorf("problem writing csv, %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package poker_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func TestLeagueCSV(t *testing.T) {
+	t.Run("writes a row per player with their rank", func(t *testing.T) {
+		var buffer bytes.Buffer
+		err := poker.WriteLeague(&buffer, poker.League{{"Chris", 33}, {"Cleo", 10}, {"Ruth", 10}}, poker.CSVFormat)
+		poker.AssertNoError(t, err)
+
+		want := "rank,name,wins\n1,Chris,33\n2,Cleo,10\n2,Ruth,10\n"
+		if buffer.String() != want {
+			t.Errorf("got %q want %q", buffer.String(), want)
+		}
+	})
+
+	t.Run("reads what it writes", func(t *testing.T) {
+		league := poker.League{{"Chris", 33}, {"Cleo, the elder", 10}}
+
+		var buffer bytes.Buffer
+		poker.AssertNoError(t, poker.WriteLeague(&buffer, league, poker.CSVFormat))
+
+		got, err := poker.ReadLeague(&buffer, poker.CSVFormat)
+		poker.AssertNoError(t, err)
+
+		poker.AssertLeague(t, got, league)
+	})
+
+	t.Run("escapes names a spreadsheet would run as formulas", func(t *testing.T) {
+		league := poker.League{{"=HYPERLINK(\"http://example.com\")", 3}, {"-Chris", 2}, {"'Cleo", 1}}
+
+		var buffer bytes.Buffer
+		poker.AssertNoError(t, poker.WriteLeague(&buffer, league, poker.CSVFormat))
+
+		want := "rank,name,wins\n1,\"'=HYPERLINK(\"\"http://example.com\"\")\",3\n2,'-Chris,2\n3,'Cleo,1\n"
+		if buffer.String() != want {
+			t.Errorf("got %q want %q", buffer.String(), want)
+		}
+
+		got, err := poker.ReadLeague(&buffer, poker.CSVFormat)
+		poker.AssertNoError(t, err)
+
+		poker.AssertLeague(t, got, league)
+	})
+
+	t.Run("finds the name and wins columns of a spreadsheet", func(t *testing.T) {
+		got, err := poker.ReadLeague(strings.NewReader("Wins,Club,Name\n4,North,Chris\n2,South,Cleo\n"), poker.CSVFormat)
+		poker.AssertNoError(t, err)
+
+		poker.AssertLeague(t, got, poker.League{{"Chris", 4}, {"Cleo", 2}})
+	})
+
+	for name, data := range map[string]string{
+		"without headings":   "Chris,4\n",
+		"with bad wins":      "name,wins\nChris,four\n",
+		"with nothing in it": "",
+	} {
+		t.Run("rejects a file "+name, func(t *testing.T) {
+			if _, err := poker.ReadLeague(strings.NewReader(data), poker.CSVFormat); !errors.Is(err, poker.ErrInvalidImport) {
+				t.Errorf("got error %v want %v", err, poker.ErrInvalidImport)
+			}
+		})
+	}
+
+	t.Run("rejects an unknown format", func(t *testing.T) {
+		if _, err := poker.ReadLeague(strings.NewReader(""), "xlsx"); !errors.Is(err, poker.ErrUnknownFormat) {
+			t.Errorf("got error %v want %v", err, poker.ErrUnknownFormat)
+		}
+	})
+}
+
+func TestGamesCSV(t *testing.T) {
+	var buffer bytes.Buffer
+	poker.AssertNoError(t, poker.WriteGames(&buffer, statsGames[:1], poker.CSVFormat))
+
+	want := "id,started_at,finished_at,number_of_players,players,winner,highest_blind\n" +
+		"1,2026-10-01T19:00:00Z,2026-10-01T20:00:00Z,2,Chris;Cleo,Chris,0\n"
+
+	if buffer.String() != want {
+		t.Errorf("got %q want %q", buffer.String(), want)
+	}
+
+	buffer.Reset()
+	poker.AssertNoError(t, poker.WriteGames(&buffer, []poker.GameRecord{{ID: 2, Players: []string{"@Chris", "Cleo"}, Winner: "@Chris"}}, poker.CSVFormat))
+
+	if want := "'@Chris;Cleo,'@Chris,"; !strings.Contains(buffer.String(), want) {
+		t.Errorf("got %q want the names escaped, %q", buffer.String(), want)
+	}
+}
+
+func TestFileSystemPlayerStoreImportLeague(t *testing.T) {
+	imported := poker.League{{"chris", 40}, {"Cleo", 10}, {"Ruth", 3}}
+
+	cases := []struct {
+		conflicts string
+		chris     int
+	}{
+		{poker.KeepExisting, 33},
+		{poker.UseImported, 40},
+		{poker.AddImported, 73},
+	}
+
+	for _, c := range cases {
+		t.Run("settles conflicts with "+c.conflicts, func(t *testing.T) {
+			store := mustMakeAliasStore(t)
+
+			report, err := store.ImportLeague(imported, poker.ImportOptions{Conflicts: c.conflicts})
+			poker.AssertNoError(t, err)
+
+			want := poker.ImportReport{
+				Added:     []poker.Player{{"Ruth", 3}},
+				Unchanged: 1,
+				Conflicts: []poker.ImportConflict{{Name: "chris", Existing: 33, Imported: 40, Result: c.chris}},
+			}
+			if !reflect.DeepEqual(report, want) {
+				t.Errorf("got report %+v want %+v", report, want)
+			}
+
+			poker.AssertScore(t, store, "Chris", c.chris)
+			poker.AssertScore(t, store, "Ruth", 3)
+		})
+	}
+
+	t.Run("only reports what would change in a dry run", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		report, err := store.ImportLeague(imported, poker.ImportOptions{Conflicts: poker.UseImported, DryRun: true})
+		poker.AssertNoError(t, err)
+
+		if !report.DryRun || len(report.Added) != 1 || len(report.Conflicts) != 1 {
+			t.Errorf("got report %+v want a dry run adding Ruth", report)
+		}
+
+		poker.AssertScore(t, store, "Chris", 33)
+		poker.AssertScore(t, store, "Ruth", 0)
+	})
+
+	t.Run("logs the changes it makes so they can be undone", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		_, err := store.ImportLeague(imported, poker.ImportOptions{Conflicts: poker.UseImported, Reason: "online league"})
+		poker.AssertNoError(t, err)
+
+		entry := lastAuditEntry(t, store)
+		if entry.Reason != "online league" {
+			t.Errorf("got audit entry %+v want one for the import", entry)
+		}
+
+		poker.AssertNoError(t, store.Undo(entry.ID))
+		poker.AssertScore(t, store, "Ruth", 0)
+	})
+
+	t.Run("keeps the league sorted and drops players left without wins", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		_, err := store.ImportLeague(poker.League{{"Cleo", 0}, {"Ruth", 40}, {"Ana", 33}}, poker.ImportOptions{Conflicts: poker.UseImported})
+		poker.AssertNoError(t, err)
+
+		poker.AssertLeague(t, poker.MustGetLeague(t, store), []poker.Player{{"Ruth", 40}, {"Ana", 33}, {"Chris", 33}})
+	})
+
+	t.Run("rejects a player imported twice", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		_, err := store.ImportLeague(poker.League{{"Ruth", 3}, {"ruth ", 2}}, poker.ImportOptions{})
+
+		if !errors.Is(err, poker.ErrInvalidImport) {
+			t.Errorf("got error %v want %v", err, poker.ErrInvalidImport)
+		}
+		poker.AssertScore(t, store, "Ruth", 0)
+	})
+
+	t.Run("rejects an unknown conflict policy", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		if _, err := store.ImportLeague(imported, poker.ImportOptions{Conflicts: "toss"}); !errors.Is(err, poker.ErrUnknownConflict) {
+			t.Errorf("got error %v want %v", err, poker.ErrUnknownConflict)
+		}
+	})
+}
+
+func TestImportExportOverHTTP(t *testing.T) {
+	t.Run("GET /league.csv returns the league as CSV", func(t *testing.T) {
+		store := &poker.StubPlayerStore{League: []poker.Player{{"Chris", 33}, {"Cleo", 10}}}
+		server, _ := poker.NewPlayerServer(store, &poker.StubGameStore{}, dummyGame)
+
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetLeagueCSVRequest())
+
+		assertStatus(t, response, http.StatusOK)
+		poker.AssertContentType(t, response, "text/csv")
+		poker.AssertResponseBody(t, response.Body.String(), "rank,name,wins\n1,Chris,33\n2,Cleo,10\n")
+	})
+
+	t.Run("GET /games.csv returns the game history as CSV", func(t *testing.T) {
+		server, _ := poker.NewPlayerServer(&poker.StubPlayerStore{}, &poker.StubGameStore{Games: statsGames}, dummyGame)
+
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetGamesCSVRequest())
+
+		assertStatus(t, response, http.StatusOK)
+
+		if rows := strings.Count(response.Body.String(), "\n"); rows != len(statsGames)+1 {
+			t.Errorf("got %d rows want %d", rows, len(statsGames)+1)
+		}
+	})
+
+	t.Run("POST /admin/import merges an uploaded file", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+		server, _ := poker.NewPlayerServer(store, &poker.StubGameStore{}, dummyGame)
+		server.AdminToken = adminToken
+
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewImportRequest("results.csv", "name,wins\nRuth,3\nChris,40\n", map[string]string{"conflicts": "replace"}, adminToken))
+
+		var report poker.ImportReport
+		decodeJSON(t, response, &report)
+
+		if len(report.Added) != 1 || len(report.Conflicts) != 1 {
+			t.Errorf("got report %+v want Ruth added and a conflict for Chris", report)
+		}
+		poker.AssertScore(t, store, "Chris", 40)
+	})
+
+	t.Run("POST /admin/import reads JSON and does dry runs", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+		server, _ := poker.NewPlayerServer(store, &poker.StubGameStore{}, dummyGame)
+		server.AdminToken = adminToken
+
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewImportRequest("results.json", `[{"Name":"Ruth","Wins":3}]`, map[string]string{"dry-run": "true"}, adminToken))
+
+		var report poker.ImportReport
+		decodeJSON(t, response, &report)
+
+		if !report.DryRun || len(report.Added) != 1 {
+			t.Errorf("got report %+v want a dry run adding Ruth", report)
+		}
+		poker.AssertScore(t, store, "Ruth", 0)
+	})
+
+	t.Run("POST /admin/import returns 400 for a file it can't read", func(t *testing.T) {
+		server, _ := poker.NewPlayerServer(mustMakeAliasStore(t), &poker.StubGameStore{}, dummyGame)
+		server.AdminToken = adminToken
+
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewImportRequest("results.xlsx", "", nil, adminToken))
+
+		assertStatus(t, response, http.StatusBadRequest)
+	})
+
+	t.Run("POST /admin/import needs the admin token", func(t *testing.T) {
+		server, _ := poker.NewPlayerServer(mustMakeAliasStore(t), &poker.StubGameStore{}, dummyGame)
+		server.AdminToken = adminToken
+
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewImportRequest("results.csv", "name,wins\n", nil, ""))
+
+		assertStatus(t, response, http.StatusUnauthorized)
+	})
+}
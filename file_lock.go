@@ -0,0 +1,28 @@
+package poker
+
+import (
+	"fmt"
+	"os"
+)
+
+const lockSuffix = ".lock"
+
+// fileLock is an advisory lock held on a file next to the database. The
+// database itself is replaced on every write, so it can't carry the lock.
+type fileLock struct {
+	file *os.File
+}
+
+func openFileLock(path string) (*fileLock, error) {
+	file, err := os.OpenFile(path+lockSuffix, os.O_RDWR|os.O_CREATE, 0666)
+
+	if err != nil {
+		return nil, fmt.Errorf("problem opening lock file for %s, %v", path, err)
+	}
+
+	return &fileLock{file: file}, nil
+}
+
+func (l *fileLock) Close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package poker
+
+// Advisory locks are only supported on unix, elsewhere processes sharing a
+// database are not protected from each other.
+
+func (l *fileLock) Lock() error {
+	return nil
+}
+
+func (l *fileLock) Unlock() error {
+	return nil
+}
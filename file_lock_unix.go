@@ -0,0 +1,22 @@
+//go:build unix
+
+package poker
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func (l *fileLock) Lock() error {
+	if err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("problem locking %s, %v", l.file.Name(), err)
+	}
+	return nil
+}
+
+func (l *fileLock) Unlock() error {
+	if err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN); err != nil {
+		return fmt.Errorf("problem unlocking %s, %v", l.file.Name(), err)
+	}
+	return nil
+}
@@ -3,96 +3,399 @@ package poker
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"sort"
+	"sync"
 )
 
+// FileSystemPlayerStore keeps the league, its seasons, aliases and audit log
+// in one JSON file. Every change copies the whole database and rewrites the
+// whole file, so a write costs as much as the league is big: it suits a home
+// game's league, the event log and sqlite stores are the ones that scale.
 type FileSystemPlayerStore struct {
-	database *json.Encoder
-	league   League
+	*playerFile
+
+	// source is logged against every change made through the store, see From.
+	source Source
+}
+
+// playerFile is the state shared by a store and the views of it From returns.
+type playerFile struct {
+	mu        sync.RWMutex
+	database  *json.Encoder
+	tape      *Tape
+	lock      *fileLock
+	db        playerDB
+	recovered *Recovery
+	migrated  *MigrationReport
+
+	// cipher encrypts the file, its backup and its snapshots, or is nil when
+	// they're kept in plain text.
+	cipher *fileCipher
+
+	// version counts the changes made to db, see Version.
+	version uint64
+
+	// seen is the version of the file the store last wrote or loaded, see
+	// Watch.
+	seen os.FileInfo
+
+	// pending are the wins recorded in write-behind mode that are yet to be
+	// saved, flush is signalled when there are maxPending of them.
+	pending    []pendingWin
+	flush      chan struct{}
+	maxPending int
+
+	// KeepSnapshots is the number of snapshots kept, older ones are removed
+	// when a new one is taken. Zero keeps them all.
+	KeepSnapshots int
+
+	// KeepAudit is the number of entries kept in the audit log, the oldest
+	// are dropped as changes are saved. Zero keeps them all.
+	KeepAudit int
+}
+
+// Recovery describes a database that could not be loaded and was restored
+// from its last good copy.
+type Recovery struct {
+	Path       string
+	BackupPath string
+	Players    int
+	Cause      error
+}
+
+func (r Recovery) String() string {
+	return fmt.Sprintf("recovered %d players for %s from %s after: %v", r.Players, r.Path, r.BackupPath, r.Cause)
 }
 
-func FileSystemPlayerStoreFromFile(path string) (*FileSystemPlayerStore, func(), error) {
+func FileSystemPlayerStoreFromFile(path string, options ...FileSystemOption) (*FileSystemPlayerStore, func(), error) {
 	db, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
 
 	if err != nil {
 		return nil, nil, fmt.Errorf("problem opening %s %v", path, err)
 	}
 
-	closeFunc := func() {
+	lock, err := openFileLock(path)
+
+	if err != nil {
 		db.Close()
+		return nil, nil, err
 	}
 
-	store, err := NewFileSystemPlayerStore(db)
+	if err := lock.Lock(); err != nil {
+		db.Close()
+		lock.Close()
+		return nil, nil, err
+	}
+
+	store, err := NewFileSystemPlayerStore(db, options...)
+	lock.Unlock()
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("problem creating file system player store, %v ", err)
+		db.Close()
+		lock.Close()
+		return nil, nil, fmt.Errorf("problem creating file system player store, %w ", err)
+	}
+
+	store.lock = lock
+
+	closeFunc := func() {
+		store.tape.File.Close()
+		lock.Close()
 	}
 
 	return store, closeFunc, nil
 }
 
-func NewFileSystemPlayerStore(file *os.File) (*FileSystemPlayerStore, error) {
+func NewFileSystemPlayerStore(file *os.File, options ...FileSystemOption) (*FileSystemPlayerStore, error) {
+	opts, err := applyFileOptions(options)
 
-	err := initialisePlayerDBFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	err = initialisePlayerDBFile(file, opts.cipher)
 
 	if err != nil {
 		return nil, fmt.Errorf("Problem intialising player db file, %v", err)
 	}
 
-	league, err := NewLeague(file)
+	tape := &Tape{File: file}
+	var recovered *Recovery
+
+	db, migration, err := loadPlayerDB(file, opts.cipher)
 
 	if err != nil {
-		return nil, fmt.Errorf("Problem loading player store from %s, %v", file.Name(), err)
+		db, migration, recovered, err = recoverFromBackup(tape, err, opts.cipher)
 	}
 
-	return &FileSystemPlayerStore{
-		database: json.NewEncoder(&Tape{File: file}),
-		league:   league,
-	}, nil
+	if err != nil {
+		return nil, fmt.Errorf("Problem loading player store from %s, %w", file.Name(), err)
+	}
+
+	store := &FileSystemPlayerStore{playerFile: &playerFile{
+		database:      json.NewEncoder(sealingWriter{tape, opts.cipher}),
+		tape:          tape,
+		db:            db,
+		recovered:     recovered,
+		cipher:        opts.cipher,
+		KeepSnapshots: defaultKeepSnapshots,
+		KeepAudit:     defaultKeepAudit,
+	}}
+
+	store.markSeen()
+
+	if len(migration.Steps) > 0 {
+		migration.Path = file.Name()
+		store.migrated = &migration
+
+		if err := store.save(db); err != nil {
+			return nil, fmt.Errorf("Problem saving migrated player store to %s, %v", file.Name(), err)
+		}
+	}
+
+	return store, nil
+}
+
+// Version counts the changes made to the store, including wins waiting to be
+// written behind and changes reloaded from the file.
+func (f *FileSystemPlayerStore) Version() uint64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.version
+}
+
+// Recovered reports whether the database was restored from its last good
+// copy when the store was opened, or nil if it loaded cleanly.
+func (f *FileSystemPlayerStore) Recovered() *Recovery {
+	return f.recovered
+}
+
+// Migrated reports the schema migrations applied to the database when the
+// store was opened, or nil if it was already at the current version.
+func (f *FileSystemPlayerStore) Migrated() *MigrationReport {
+	return f.migrated
+}
+
+// GetLeague returns the standings of the active season.
+func (f *FileSystemPlayerStore) GetLeague() (League, error) {
+	f.mu.RLock()
+	league := f.db.activeSeason().standings()
+	f.mu.RUnlock()
+
+	return league, nil
 }
 
-func (f *FileSystemPlayerStore) GetLeague() League {
-	sort.Slice(f.league, func(i, j int) bool {
-		return f.league[i].Wins > f.league[j].Wins
+// GetPlayerScore returns the player's wins in the active season.
+func (f *FileSystemPlayerStore) GetPlayerScore(name string) (int, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.db.activeSeason().wins(f.db.resolve(name)), nil
+}
+
+// RecordWin adds a win to the player, or the player an alias resolves to, in
+// the active season. In write-behind mode the win is saved with the next
+// batch, see WriteBehind.
+func (f *FileSystemPlayerStore) RecordWin(name string) error {
+	if f.recordWinBehind(name) {
+		return nil
+	}
+
+	err := f.update(func(db *playerDB) error {
+		db.recordWin(name)
+		return nil
 	})
-	return f.league
+
+	if err != nil {
+		return fmt.Errorf("problem saving win for %s, %v", name, err)
+	}
+
+	return nil
+}
+
+func (db *playerDB) recordWin(name string) {
+	season := db.activeSeason()
+	winner := db.resolve(name)
+	wins := season.wins(winner)
+
+	season.setWins(winner, wins+1)
+
+	db.log(auditRecord{Action: winAction, Player: winner, Before: wins, After: wins + 1})
+}
+
+// update applies change to a copy of the database and saves it. It holds the
+// database's lock file, when the store has one, and re-reads the file first
+// if another process has written it, so their changes are kept. Entries the
+// change adds to the audit log are numbered and stamped with the store's
+// source, and the oldest entries past KeepAudit are dropped. Wins waiting to be written behind are saved along with the change.
+func (f *FileSystemPlayerStore) update(change func(db *playerDB) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.lock != nil {
+		if err := f.lock.Lock(); err != nil {
+			return err
+		}
+		defer f.lock.Unlock()
+
+		if err := f.reloadIfStale(); err != nil {
+			return err
+		}
+	}
+
+	db := f.db.clone()
+
+	if err := change(&db); err != nil {
+		return err
+	}
+
+	db.stampAudit(f.source)
+	db.trimAudit(f.KeepAudit)
+
+	if err := f.save(db); err != nil {
+		return err
+	}
+
+	f.db = db
+	f.pending = nil
+	f.version++
+	return nil
+}
+
+func (f *FileSystemPlayerStore) save(db playerDB) error {
+	db.Version = currentSchemaVersion
+
+	if err := f.database.Encode(db); err != nil {
+		return err
+	}
+
+	f.markSeen()
+	return nil
+}
+
+func (f *FileSystemPlayerStore) reload() error {
+	path := f.tape.File.Name()
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return fmt.Errorf("problem re-reading %s, %v", path, err)
+	}
+
+	if data, err = f.cipher.open(data); err != nil {
+		f.tape.rejected = true
+		return fmt.Errorf("problem re-reading %s, %w", path, err)
+	}
+
+	db, _, err := decodePlayerDB(data)
+
+	if err != nil {
+		f.tape.rejected = true
+		return fmt.Errorf("problem re-reading %s, %v", path, err)
+	}
+
+	f.tape.rejected = false
+
+	// wins waiting to be written behind aren't in the file yet
+	for _, win := range f.pending {
+		db.applyWin(win)
+	}
+
+	f.db = db
+	f.version++
+	return nil
 }
 
-func (f *FileSystemPlayerStore) GetPlayerScore(name string) int {
-	player := f.league.Find(name)
+func loadPlayerDB(file *os.File, cipher *fileCipher) (playerDB, MigrationReport, error) {
+	data, err := io.ReadAll(file)
+
+	if err != nil {
+		return playerDB{}, MigrationReport{}, fmt.Errorf("Problem reading file %s, %v", file.Name(), err)
+	}
 
-	if player != nil {
-		return player.Wins
+	if len(data) == 0 && backupExists(file.Name()) {
+		return playerDB{}, MigrationReport{}, fmt.Errorf("%s is empty but a backup exists", file.Name())
 	}
 
-	return 0
+	if data, err = cipher.open(data); err != nil {
+		return playerDB{}, MigrationReport{}, fmt.Errorf("Problem reading file %s, %w", file.Name(), err)
+	}
 
+	return decodePlayerDB(data)
 }
 
-func (f *FileSystemPlayerStore) RecordWin(name string) {
-	player := f.league.Find(name)
+func recoverFromBackup(tape *Tape, cause error, cipher *fileCipher) (playerDB, MigrationReport, *Recovery, error) {
+	path := tape.File.Name()
+	backupPath := path + backupSuffix
+
+	data, err := os.ReadFile(backupPath)
 
-	if player != nil {
-		player.Wins++
-	} else {
-		f.league = append(f.league, Player{name, 1})
+	if err != nil {
+		return playerDB{}, MigrationReport{}, nil, cause
+	}
+
+	plain, err := cipher.open(data)
+
+	if err != nil {
+		return playerDB{}, MigrationReport{}, nil, fmt.Errorf("%w, and backup %s is unusable, %v", cause, backupPath, err)
 	}
 
-	f.database.Encode(f.league)
+	db, migration, err := decodePlayerDB(plain)
+
+	if err != nil {
+		return playerDB{}, migration, nil, fmt.Errorf("%v, and backup %s is unusable, %v", cause, backupPath, err)
+	}
+
+	if err := writeFileAtomically(path, data); err != nil {
+		return playerDB{}, migration, nil, fmt.Errorf("problem restoring %s from %s, %v", path, backupPath, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0666)
+
+	if err != nil {
+		return playerDB{}, migration, nil, fmt.Errorf("problem reopening %s, %v", path, err)
+	}
+
+	tape.File.Close()
+	tape.File = file
+
+	return db, migration, &Recovery{
+		Path:       path,
+		BackupPath: backupPath,
+		Players:    db.activeSeason().league.len(),
+		Cause:      cause,
+	}, nil
+}
+
+func backupExists(path string) bool {
+	_, err := os.Stat(path + backupSuffix)
+	return err == nil
 }
 
-func initialisePlayerDBFile(file *os.File) error {
-	file.Seek(0, 0)
+func initialisePlayerDBFile(file *os.File, cipher *fileCipher) error {
+	if _, err := file.Seek(0, 0); err != nil {
+		return fmt.Errorf("Problem seeking in file %s, %v", file.Name(), err)
+	}
+
 	info, err := file.Stat()
 
 	if err != nil {
 		return fmt.Errorf("Problem getting file info from file %s, %v", file.Name(), err)
 	}
 
-	if info.Size() == 0 {
-		file.Write([]byte("[]"))
-		file.Seek(0, 0)
+	if info.Size() == 0 && !backupExists(file.Name()) {
+		if err := json.NewEncoder(sealingWriter{file, cipher}).Encode(newPlayerDB()); err != nil {
+			return fmt.Errorf("Problem initialising %s, %v", file.Name(), err)
+		}
+
+		if err := file.Sync(); err != nil {
+			return fmt.Errorf("Problem syncing %s, %v", file.Name(), err)
+		}
+
+		if _, err := file.Seek(0, 0); err != nil {
+			return fmt.Errorf("Problem seeking in file %s, %v", file.Name(), err)
+		}
 	}
 
 	return nil
@@ -3,6 +3,8 @@ package poker_test
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 
 	poker "github.com/ljones140/golang-player-webserver"
@@ -17,7 +19,7 @@ func TestFilesSystemStore(t *testing.T) {
 
 		store, err := poker.NewFileSystemPlayerStore(database)
 
-		got := store.GetLeague()
+		got := poker.MustGetLeague(t, store)
 
 		want := []poker.Player{
 			{"Chris", 33},
@@ -29,7 +31,7 @@ func TestFilesSystemStore(t *testing.T) {
 		poker.AssertLeague(t, got, want)
 
 		// read again
-		got = store.GetLeague()
+		got = poker.MustGetLeague(t, store)
 		poker.AssertLeague(t, got, want)
 	})
 
@@ -41,7 +43,9 @@ func TestFilesSystemStore(t *testing.T) {
 
 		store, err := poker.NewFileSystemPlayerStore(database)
 
-		got := store.GetPlayerScore("Chris")
+		poker.AssertNoError(t, err)
+
+		got, err := store.GetPlayerScore("Chris")
 
 		want := 33
 
@@ -59,9 +63,10 @@ func TestFilesSystemStore(t *testing.T) {
 		store, err := poker.NewFileSystemPlayerStore(database)
 		poker.AssertNoError(t, err)
 
-		store.RecordWin("Chris")
+		poker.MustRecordWin(t, store, "Chris")
 
-		got := store.GetPlayerScore("Chris")
+		got, err := store.GetPlayerScore("Chris")
+		poker.AssertNoError(t, err)
 		want := 34
 		assertScoreEquals(t, got, want)
 	})
@@ -75,9 +80,10 @@ func TestFilesSystemStore(t *testing.T) {
 		store, err := poker.NewFileSystemPlayerStore(database)
 		poker.AssertNoError(t, err)
 
-		store.RecordWin("Pepper")
+		poker.MustRecordWin(t, store, "Pepper")
 
-		got := store.GetPlayerScore("Pepper")
+		got, err := store.GetPlayerScore("Pepper")
+		poker.AssertNoError(t, err)
 		want := 1
 		assertScoreEquals(t, got, want)
 	})
@@ -91,6 +97,88 @@ func TestFilesSystemStore(t *testing.T) {
 		poker.AssertNoError(t, err)
 	})
 
+	t.Run("recovers a corrupt file from its backup", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `[{"Name": "Cleo", "Wi`)
+		defer cleanDatabase()
+		writeFile(t, database.Name()+".bak", `[{"Name": "Cleo", "Wins": 10}]`)
+
+		store, err := poker.NewFileSystemPlayerStore(database)
+		poker.AssertNoError(t, err)
+
+		poker.AssertScore(t, store, "Cleo", 10)
+
+		recovery := store.Recovered()
+		if recovery == nil {
+			t.Fatal("expected the store to report a recovery")
+		}
+
+		if recovery.Players != 1 {
+			t.Errorf("got %d recovered players want %d", recovery.Players, 1)
+		}
+
+		reopened, closeReopened, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeReopened()
+
+		poker.AssertScore(t, reopened, "Cleo", 10)
+
+		if reopened.Recovered() != nil {
+			t.Errorf("didn't expect a second recovery but got %v", reopened.Recovered())
+		}
+	})
+
+	t.Run("recovers an emptied file from its backup", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+		writeFile(t, database.Name()+".bak", `[{"Name": "Cleo", "Wins": 10}]`)
+
+		store, err := poker.NewFileSystemPlayerStore(database)
+		poker.AssertNoError(t, err)
+
+		poker.AssertScore(t, store, "Cleo", 10)
+	})
+
+	t.Run("errors on a corrupt file without a backup", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `[{"Name": "Cleo", "Wi`)
+		defer cleanDatabase()
+
+		_, err := poker.NewFileSystemPlayerStore(database)
+
+		if err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+
+	t.Run("does not report a recovery for a good file", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `[{"Name": "Cleo", "Wins": 10}]`)
+		defer cleanDatabase()
+
+		store, err := poker.NewFileSystemPlayerStore(database)
+		poker.AssertNoError(t, err)
+
+		if store.Recovered() != nil {
+			t.Errorf("didn't expect a recovery but got %v", store.Recovered())
+		}
+	})
+
+	t.Run("keeps recorded wins across reopening the file", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `[{"Name": "Cleo", "Wins": 10}]`)
+		defer cleanDatabase()
+
+		store, closeStore, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		poker.MustRecordWin(t, store, "Cleo")
+		poker.MustRecordWin(t, store, "Chris")
+		closeStore()
+
+		reopened, closeReopened, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeReopened()
+
+		poker.AssertScore(t, reopened, "Cleo", 11)
+		poker.AssertScore(t, reopened, "Chris", 1)
+	})
+
 	t.Run("sorts league", func(t *testing.T) {
 		database, cleanDatabase := createTempFile(t, `[
       {"Name": "Cleo", "Wins": 10},
@@ -100,7 +188,7 @@ func TestFilesSystemStore(t *testing.T) {
 		store, err := poker.NewFileSystemPlayerStore(database)
 		poker.AssertNoError(t, err)
 
-		got := store.GetLeague()
+		got := poker.MustGetLeague(t, store)
 
 		want := []poker.Player{
 			{"Chris", 33},
@@ -109,20 +197,44 @@ func TestFilesSystemStore(t *testing.T) {
 
 		poker.AssertLeague(t, got, want)
 		// read again
-		got = store.GetLeague()
+		got = poker.MustGetLeague(t, store)
 		poker.AssertLeague(t, got, want)
 	})
 
+	t.Run("keeps the league sorted as players change", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `[
+      {"Name": "Cleo", "Wins": 10},
+      {"Name": "Chris", "Wins": 33},
+      {"Name": "Ana", "Wins": 10}]`)
+		defer cleanDatabase()
+
+		store, err := poker.NewFileSystemPlayerStore(database)
+		poker.AssertNoError(t, err)
+
+		poker.MustRecordWin(t, store, "Bob")
+		poker.AssertNoError(t, store.SetPlayerScore("Bob", 10, "typo"))
+		poker.AssertNoError(t, store.SetPlayerScore("Chris", 9, "typo"))
+		poker.AssertNoError(t, store.RenamePlayer("Ana", "Dan", "full name"))
+		poker.AssertNoError(t, store.DeletePlayer("Cleo", "left"))
+		poker.MustRecordWin(t, store, "Chris")
+
+		poker.AssertLeague(t, poker.MustGetLeague(t, store), []poker.Player{
+			{"Bob", 10},
+			{"Chris", 10},
+			{"Dan", 10},
+		})
+	})
+
 }
 
 func assertScoreEquals(t testing.TB, got, want int) {
 	t.Helper()
 	if got != want {
-		t.Errorf("got %q, want %q", got, want)
+		t.Errorf("got %d, want %d", got, want)
 	}
 }
 
-func createTempFile(t *testing.T, initialData string) (*os.File, func()) {
+func createTempFile(t testing.TB, initialData string) (*os.File, func()) {
 	t.Helper()
 
 	tmpfile, err := ioutil.TempFile("", "db")
@@ -136,7 +248,68 @@ func createTempFile(t *testing.T, initialData string) (*os.File, func()) {
 	removeFile := func() {
 		tmpfile.Close()
 		os.Remove(tmpfile.Name())
+
+		sidecars, _ := filepath.Glob(tmpfile.Name() + ".*")
+		for _, sidecar := range sidecars {
+			os.RemoveAll(sidecar)
+		}
 	}
 
 	return tmpfile, removeFile
 }
+
+func TestFileSystemStoresSharingAFile(t *testing.T) {
+	t.Run("keeps wins recorded through another store", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `[]`)
+		defer cleanDatabase()
+
+		atTable, closeAtTable, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeAtTable()
+
+		online, closeOnline, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeOnline()
+
+		poker.MustRecordWin(t, atTable, "Chris")
+		poker.MustRecordWin(t, online, "Chris")
+		poker.MustRecordWin(t, atTable, "Cleo")
+
+		poker.AssertScore(t, atTable, "Chris", 2)
+		poker.AssertScore(t, atTable, "Cleo", 1)
+	})
+
+	t.Run("does not lose concurrent wins", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `[]`)
+		defer cleanDatabase()
+
+		first, closeFirst, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeFirst()
+
+		second, closeSecond, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeSecond()
+
+		winsEach := 20
+		var wg sync.WaitGroup
+		for _, store := range []*poker.FileSystemPlayerStore{first, second} {
+			wg.Add(1)
+			go func(store *poker.FileSystemPlayerStore) {
+				defer wg.Done()
+				for i := 0; i < winsEach; i++ {
+					if err := store.RecordWin("Chris"); err != nil {
+						t.Error(err)
+					}
+				}
+			}(store)
+		}
+		wg.Wait()
+
+		reopened, closeReopened, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeReopened()
+
+		poker.AssertScore(t, reopened, "Chris", 2*winsEach)
+	})
+}
@@ -2,7 +2,17 @@ package poker
 
 import "io"
 
+// Game is a game of poker. Start is given the players' names when they are
+// known, otherwise just how many are playing, and returns the game being
+// played so that several can be played at once.
 type Game interface {
-	Start(numberOfPlayers int, alertsDestination io.Writer)
-	Finish(winner string)
+	Start(numberOfPlayers int, players []string, alertsDestination io.Writer) (GameInProgress, error)
+}
+
+// GameInProgress is a game that has been started and is waiting for its
+// winner. ID is the game's ID in the game history, handed out at the start
+// when the game store is a GameOpener, otherwise 0 until the game is recorded.
+type GameInProgress interface {
+	ID() int
+	Finish(winner string, source Source) error
 }
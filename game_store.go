@@ -0,0 +1,343 @@
+package poker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrGameNotFound = errors.New("game not found")
+
+type GameStore interface {
+	RecordGame(game GameRecord) (GameRecord, error)
+	GetGames() ([]GameRecord, error)
+	GetGame(id int) (GameRecord, error)
+}
+
+// GameOpener is implemented by game stores that hand out a game's ID when it
+// starts, so money can be recorded against the game while it's played. The
+// ID stays open until the game is recorded with it.
+type GameOpener interface {
+	OpenGame() (int, error)
+}
+
+// GameRecord is a finished game. Players holds the participants' names when
+// they were given at the start of the game, and Ratings how the game changed
+// their ratings.
+type GameRecord struct {
+	ID              int
+	StartedAt       time.Time
+	FinishedAt      time.Time
+	NumberOfPlayers int
+	Players         []string
+	Winner          string
+	HighestBlind    int
+	Ratings         []PlayerRating
+}
+
+func (g GameRecord) Duration() time.Duration {
+	return g.FinishedAt.Sub(g.StartedAt)
+}
+
+type gameDB struct {
+	Version int           `json:"version"`
+	Games   []GameRecord  `json:"games"`
+	Ledger  []LedgerEntry `json:"ledger"`
+	Open    []int         `json:"open,omitempty"`
+}
+
+// gameDBVersion 2 added ratings to games, 3 the money ledger and 4 the IDs of
+// the games being played, older files load as they are.
+const gameDBVersion = 4
+
+// FileSystemGameStore keeps the game history and ledger in a file. A store
+// opened with FileSystemGameStoreFromFile locks the file while it writes and
+// reloads anything another process has written first, so the CLI and the
+// webserver can share it.
+type FileSystemGameStore struct {
+	mu       sync.RWMutex
+	database *json.Encoder
+	tape     *Tape
+	lock     *fileLock
+	cipher   *fileCipher
+	seen     os.FileInfo
+	games    []GameRecord
+	ledger   []LedgerEntry
+	open     []int
+
+	// Rater rates the players of each game recorded.
+	Rater RatingAlgorithm
+}
+
+func FileSystemGameStoreFromFile(path string, options ...FileSystemOption) (*FileSystemGameStore, func(), error) {
+	db, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("problem opening %s %v", path, err)
+	}
+
+	lock, err := openFileLock(path)
+
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	if err := lock.Lock(); err != nil {
+		db.Close()
+		lock.Close()
+		return nil, nil, err
+	}
+
+	store, err := NewFileSystemGameStore(db, options...)
+	lock.Unlock()
+
+	if err != nil {
+		db.Close()
+		lock.Close()
+		return nil, nil, fmt.Errorf("problem creating file system game store, %w ", err)
+	}
+
+	store.lock = lock
+
+	closeFunc := func() {
+		store.tape.File.Close()
+		lock.Close()
+	}
+
+	return store, closeFunc, nil
+}
+
+func NewFileSystemGameStore(file *os.File, options ...FileSystemOption) (*FileSystemGameStore, error) {
+	opts, err := applyFileOptions(options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("problem seeking in file %s, %v", file.Name(), err)
+	}
+
+	data, err := io.ReadAll(file)
+
+	if err != nil {
+		return nil, fmt.Errorf("problem reading file %s, %v", file.Name(), err)
+	}
+
+	db, err := decodeGameDB(data, file.Name(), opts.cipher)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tape := &Tape{File: file}
+
+	store := &FileSystemGameStore{
+		database: json.NewEncoder(sealingWriter{tape, opts.cipher}),
+		tape:     tape,
+		cipher:   opts.cipher,
+		games:    db.Games,
+		ledger:   db.Ledger,
+		open:     db.Open,
+		Rater:    DefaultRatingAlgorithm,
+	}
+
+	store.markSeen()
+	return store, nil
+}
+
+func decodeGameDB(data []byte, path string, cipher *fileCipher) (gameDB, error) {
+	data, err := cipher.open(data)
+
+	if err != nil {
+		return gameDB{}, fmt.Errorf("problem reading file %s, %w", path, err)
+	}
+
+	var db gameDB
+
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &db); err != nil {
+			return gameDB{}, fmt.Errorf("problem parsing game history %s, %v", path, err)
+		}
+
+		if db.Version > gameDBVersion {
+			return gameDB{}, fmt.Errorf("game history version %d is newer than supported version %d", db.Version, gameDBVersion)
+		}
+	}
+
+	return db, nil
+}
+
+// OpenGame hands out the next free ID to a game that's starting.
+func (f *FileSystemGameStore) OpenGame() (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	unlock, err := f.lockFile()
+
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	id := f.nextGameID()
+	open := append(f.open[:len(f.open):len(f.open)], id)
+
+	if err := f.save(f.games, f.ledger, open); err != nil {
+		return 0, fmt.Errorf("problem saving game, %v", err)
+	}
+
+	f.open = open
+	return id, nil
+}
+
+// RecordGame stores the game, rating its players when they are known, and
+// returns it. A game with an ID from OpenGame keeps it, any other game gets
+// the next free ID.
+func (f *FileSystemGameStore) RecordGame(game GameRecord) (GameRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	unlock, err := f.lockFile()
+
+	if err != nil {
+		return GameRecord{}, err
+	}
+	defer unlock()
+
+	open := f.open
+
+	if i := slices.Index(f.open, game.ID); game.ID != 0 && i >= 0 {
+		open = slices.Delete(slices.Clone(f.open), i, i+1)
+	} else {
+		game.ID = f.nextGameID()
+	}
+
+	game.Ratings = rateGame(f.Rater, currentRatings(f.games), game)
+	games := append(f.games[:len(f.games):len(f.games)], game)
+
+	if err := f.save(games, f.ledger, open); err != nil {
+		return GameRecord{}, fmt.Errorf("problem saving game, %v", err)
+	}
+
+	f.games = games
+	f.open = open
+	return game, nil
+}
+
+// nextGameID is one past the highest ID recorded or handed out. Games that
+// finish out of the order they started in are kept in the order they
+// finished, so the last game's ID isn't always the highest.
+func (f *FileSystemGameStore) nextGameID() int {
+	id := 0
+
+	for _, game := range f.games {
+		id = max(id, game.ID)
+	}
+
+	for _, open := range f.open {
+		id = max(id, open)
+	}
+
+	return id + 1
+}
+
+// hasGame reports whether id is a recorded game or one being played.
+func (f *FileSystemGameStore) hasGame(id int) bool {
+	return f.gameIndex(id) >= 0 || slices.Contains(f.open, id)
+}
+
+func (f *FileSystemGameStore) gameIndex(id int) int {
+	return slices.IndexFunc(f.games, func(game GameRecord) bool { return game.ID == id })
+}
+
+func (f *FileSystemGameStore) save(games []GameRecord, ledger []LedgerEntry, open []int) error {
+	if err := f.database.Encode(gameDB{Version: gameDBVersion, Games: games, Ledger: ledger, Open: open}); err != nil {
+		return err
+	}
+
+	f.markSeen()
+	return nil
+}
+
+// lockFile locks the file against other processes, if the store has a lock,
+// and reloads the history when another process has written it since the
+// store last wrote or loaded it.
+func (f *FileSystemGameStore) lockFile() (unlock func(), err error) {
+	if f.lock == nil {
+		return func() {}, nil
+	}
+
+	if err := f.lock.Lock(); err != nil {
+		return nil, err
+	}
+
+	if err := f.reloadIfStale(); err != nil {
+		f.lock.Unlock()
+		return nil, err
+	}
+
+	return func() { f.lock.Unlock() }, nil
+}
+
+func (f *FileSystemGameStore) reloadIfStale() error {
+	path := f.tape.File.Name()
+	info, err := os.Stat(path)
+
+	if err == nil && f.seen != nil && sameFileVersion(f.seen, info) {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return fmt.Errorf("problem re-reading %s, %v", path, err)
+	}
+
+	db, err := decodeGameDB(data, path, f.cipher)
+
+	if err != nil {
+		return err
+	}
+
+	f.games = db.Games
+	f.ledger = db.Ledger
+	f.open = db.Open
+	f.markSeen()
+	return nil
+}
+
+func (f *FileSystemGameStore) markSeen() {
+	if info, err := os.Stat(f.tape.File.Name()); err == nil {
+		f.seen = info
+	}
+}
+
+func (f *FileSystemGameStore) GetGames() ([]GameRecord, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	games := make([]GameRecord, len(f.games))
+	copy(games, f.games)
+	return games, nil
+}
+
+func (f *FileSystemGameStore) GetGame(id int) (GameRecord, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	i := f.gameIndex(id)
+
+	if i < 0 {
+		return GameRecord{}, ErrGameNotFound
+	}
+
+	return f.games[i], nil
+}
@@ -0,0 +1,128 @@
+package poker_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func TestFileSystemGameStore(t *testing.T) {
+	started := time.Date(2026, 10, 13, 19, 0, 0, 0, time.UTC)
+	game := poker.GameRecord{
+		StartedAt:       started,
+		FinishedAt:      started.Add(90 * time.Minute),
+		NumberOfPlayers: 5,
+		Winner:          "Ruth",
+		HighestBlind:    600,
+	}
+
+	t.Run("works with an empty file", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+
+		store, err := poker.NewFileSystemGameStore(database)
+		poker.AssertNoError(t, err)
+
+		games, err := store.GetGames()
+		poker.AssertNoError(t, err)
+
+		if len(games) != 0 {
+			t.Errorf("got %d games want none", len(games))
+		}
+	})
+
+	t.Run("records games with increasing ids", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+
+		store, err := poker.NewFileSystemGameStore(database)
+		poker.AssertNoError(t, err)
+
+		first, err := store.RecordGame(game)
+		poker.AssertNoError(t, err)
+		second, err := store.RecordGame(game)
+		poker.AssertNoError(t, err)
+
+		if first.ID != 1 || second.ID != 2 {
+			t.Errorf("got ids %d and %d want 1 and 2", first.ID, second.ID)
+		}
+
+		got, err := store.GetGame(2)
+		poker.AssertNoError(t, err)
+		assertGames(t, []poker.GameRecord{got}, []poker.GameRecord{second})
+
+		if got.Duration() != 90*time.Minute {
+			t.Errorf("got duration %v want %v", got.Duration(), 90*time.Minute)
+		}
+	})
+
+	t.Run("keeps games across reopening the file", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+
+		store, closeStore, err := poker.FileSystemGameStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		recorded, err := store.RecordGame(game)
+		poker.AssertNoError(t, err)
+		closeStore()
+
+		reopened, closeReopened, err := poker.FileSystemGameStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeReopened()
+
+		games, err := reopened.GetGames()
+		poker.AssertNoError(t, err)
+		assertGames(t, games, []poker.GameRecord{recorded})
+	})
+
+	t.Run("returns ErrGameNotFound for an unknown game", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+
+		store, err := poker.NewFileSystemGameStore(database)
+		poker.AssertNoError(t, err)
+
+		_, err = store.GetGame(1)
+
+		if !errors.Is(err, poker.ErrGameNotFound) {
+			t.Errorf("got error %v want %v", err, poker.ErrGameNotFound)
+		}
+	})
+}
+
+func TestFileSystemGameStoresSharingAFile(t *testing.T) {
+	t.Run("keeps the games and ledger entries recorded through another store", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+
+		cli, closeCLI, err := poker.FileSystemGameStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeCLI()
+
+		web, closeWeb, err := poker.FileSystemGameStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeWeb()
+
+		mustRecordGame(t, cli, poker.GameRecord{Winner: "Chris"})
+		mustRecordLedgerEntry(t, web, poker.LedgerEntry{GameID: 1, Kind: poker.BuyIn, Player: "Chris", Amount: 20})
+		second := mustRecordGame(t, cli, poker.GameRecord{Winner: "Cleo"})
+
+		if second.ID != 2 {
+			t.Errorf("got game id %d want 2", second.ID)
+		}
+
+		reopened, closeReopened, err := poker.FileSystemGameStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeReopened()
+
+		if games, _ := reopened.GetGames(); len(games) != 2 {
+			t.Errorf("got %d games want %d", len(games), 2)
+		}
+
+		if ledger, _ := reopened.GetLedger(); len(ledger) != 1 {
+			t.Errorf("got ledger %+v want Chris's buy-in", ledger)
+		}
+	})
+}
@@ -4,19 +4,32 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 )
 
 type League []Player
 
+// Find looks a player up ignoring case and extra whitespace in the name.
 func (l League) Find(name string) *Player {
+	key := playerKey(name)
 	for i, p := range l {
-		if p.Name == name {
+		if playerKey(p.Name) == key {
 			return &l[i]
 		}
 	}
 	return nil
 }
 
+// NormalisePlayerName trims a name and collapses the whitespace inside it.
+func NormalisePlayerName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// playerKey identifies a player regardless of how their name was typed.
+func playerKey(name string) string {
+	return strings.ToLower(NormalisePlayerName(name))
+}
+
 func NewLeague(rdr io.Reader) ([]Player, error) {
 	var league []Player
 	err := json.NewDecoder(rdr).Decode(&league)
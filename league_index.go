@@ -0,0 +1,141 @@
+package poker
+
+import (
+	"slices"
+	"sort"
+)
+
+// leagueIndex is a league kept in standings order as it changes, so looking a
+// player up and reading the standings never scan or sort the whole league.
+// Players are found by key and grouped into tiers of players on the same
+// wins. A change of wins only moves the player between two tiers, and within
+// a tier players are kept in name order, as sortByWins orders them. The event
+// log store keeps its league in one, and the json store one per season.
+type leagueIndex struct {
+	players map[string]*Player
+	tiers   map[int][]*Player
+
+	// wins is the number of wins of each tier, most first.
+	wins []int
+}
+
+func newLeagueIndex(league League) *leagueIndex {
+	l := &leagueIndex{players: map[string]*Player{}, tiers: map[int][]*Player{}}
+
+	// players added in standings order join the end of their tier, so
+	// building the index doesn't shift the tiers along
+	ordered := slices.Clone(league)
+	ordered.sortByWins()
+
+	for _, player := range ordered {
+		l.add(player.Name, player.Wins)
+	}
+
+	return l
+}
+
+// clone copies the index, with the players kept together in one allocation.
+func (l *leagueIndex) clone() *leagueIndex {
+	copied := &leagueIndex{
+		players: make(map[string]*Player, len(l.players)),
+		tiers:   make(map[int][]*Player, len(l.tiers)),
+		wins:    slices.Clone(l.wins),
+	}
+
+	players := make([]Player, 0, len(l.players))
+
+	for wins, tier := range l.tiers {
+		copiedTier := make([]*Player, len(tier))
+
+		for i, player := range tier {
+			players = append(players, *player)
+			copiedTier[i] = &players[len(players)-1]
+			copied.players[playerKey(player.Name)] = copiedTier[i]
+		}
+
+		copied.tiers[wins] = copiedTier
+	}
+
+	return copied
+}
+
+func (l *leagueIndex) len() int {
+	return len(l.players)
+}
+
+func (l *leagueIndex) find(name string) *Player {
+	return l.players[playerKey(name)]
+}
+
+// add adds wins, which may be negative, to the player, adding the player when
+// they're new and removing them when they're left with no wins.
+func (l *leagueIndex) add(name string, wins int) {
+	player := l.find(name)
+
+	if player == nil {
+		player = &Player{NormalisePlayerName(name), 0}
+		l.players[playerKey(name)] = player
+	} else {
+		l.leaveTier(player)
+	}
+
+	player.Wins += wins
+
+	if player.Wins <= 0 {
+		delete(l.players, playerKey(name))
+		return
+	}
+
+	l.joinTier(player)
+}
+
+// league returns a copy of the standings.
+func (l *leagueIndex) league() League {
+	league := make(League, 0, len(l.players))
+
+	for _, wins := range l.wins {
+		for _, player := range l.tiers[wins] {
+			league = append(league, *player)
+		}
+	}
+
+	return league
+}
+
+func (l *leagueIndex) joinTier(player *Player) {
+	tier, ok := l.tiers[player.Wins]
+
+	if !ok {
+		i := sort.Search(len(l.wins), func(i int) bool { return l.wins[i] < player.Wins })
+		l.wins = append(l.wins, 0)
+		copy(l.wins[i+1:], l.wins[i:])
+		l.wins[i] = player.Wins
+	}
+
+	i := sort.Search(len(tier), func(i int) bool { return tier[i].Name >= player.Name })
+	tier = append(tier, nil)
+	copy(tier[i+1:], tier[i:])
+	tier[i] = player
+
+	l.tiers[player.Wins] = tier
+}
+
+func (l *leagueIndex) leaveTier(player *Player) {
+	tier := l.tiers[player.Wins]
+	i := sort.Search(len(tier), func(i int) bool { return tier[i].Name >= player.Name })
+
+	for tier[i] != player {
+		i++
+	}
+
+	tier = append(tier[:i], tier[i+1:]...)
+
+	if len(tier) > 0 {
+		l.tiers[player.Wins] = tier
+		return
+	}
+
+	delete(l.tiers, player.Wins)
+	i = sort.Search(len(l.wins), func(i int) bool { return l.wins[i] <= player.Wins })
+	l.wins = append(l.wins[:i], l.wins[i+1:]...)
+}
@@ -0,0 +1,279 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	ErrInvalidLedgerEntry = errors.New("invalid ledger entry")
+	ErrLedgerUnbalanced   = errors.New("ledger does not balance")
+)
+
+const (
+	BuyIn   = "buy-in"
+	Rebuy   = "rebuy"
+	CashOut = "cash-out"
+	Payment = "payment"
+)
+
+// LedgerStore is implemented by game stores that keep track of the money
+// players bring to and take away from each game, and pay each other after.
+type LedgerStore interface {
+	RecordLedgerEntry(entry LedgerEntry) (LedgerEntry, error)
+	GetLedger() ([]LedgerEntry, error)
+	GetBalances() ([]Balance, error)
+}
+
+// LedgerEntry is money a player put into or took out of a game, or a payment
+// from one player To another outside any game. Amount is in the smallest unit
+// of the stakes, pence for example.
+type LedgerEntry struct {
+	ID     int
+	GameID int
+	At     time.Time
+	Kind   string
+	Player string
+	To     string
+	Amount int
+}
+
+// Balance is what a player is up, or down when negative, across every game
+// after the payments they've made and received.
+type Balance struct {
+	Player  string
+	Balance int
+}
+
+// Transfer is a payment that settles up some of the debts between players.
+type Transfer struct {
+	From   string
+	To     string
+	Amount int
+}
+
+// RecordLedgerEntry stores the entry with the next free ID and returns it.
+// Entries other than payments must belong to a recorded game, or to a game
+// being played with the ID it was handed when it started, see GameOpener.
+func (f *FileSystemGameStore) RecordLedgerEntry(entry LedgerEntry) (LedgerEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	unlock, err := f.lockFile()
+
+	if err != nil {
+		return LedgerEntry{}, err
+	}
+	defer unlock()
+
+	entry.Player = NormalisePlayerName(entry.Player)
+	entry.To = NormalisePlayerName(entry.To)
+
+	if err := f.validLedgerEntry(entry); err != nil {
+		return LedgerEntry{}, err
+	}
+
+	entry.ID = len(f.ledger) + 1
+	if entry.At.IsZero() {
+		entry.At = time.Now().UTC()
+	}
+
+	ledger := append(f.ledger[:len(f.ledger):len(f.ledger)], entry)
+
+	if err := f.save(f.games, ledger, f.open); err != nil {
+		return LedgerEntry{}, fmt.Errorf("problem saving ledger entry, %v", err)
+	}
+
+	f.ledger = ledger
+	return entry, nil
+}
+
+func (f *FileSystemGameStore) validLedgerEntry(entry LedgerEntry) error {
+	if entry.Player == "" || entry.Amount <= 0 {
+		return fmt.Errorf("%w: needs a player and an amount above zero", ErrInvalidLedgerEntry)
+	}
+
+	switch entry.Kind {
+	case BuyIn, Rebuy, CashOut:
+		if !f.hasGame(entry.GameID) {
+			return fmt.Errorf("%w: %w %d", ErrInvalidLedgerEntry, ErrGameNotFound, entry.GameID)
+		}
+	case Payment:
+		if entry.To == "" || playerKey(entry.To) == playerKey(entry.Player) {
+			return fmt.Errorf("%w: a payment needs someone else to pay", ErrInvalidLedgerEntry)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidLedgerEntry, entry.Kind)
+	}
+
+	return nil
+}
+
+func (f *FileSystemGameStore) GetLedger() ([]LedgerEntry, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	ledger := make([]LedgerEntry, len(f.ledger))
+	copy(ledger, f.ledger)
+	return ledger, nil
+}
+
+// GetBalances returns every player's running balance, biggest winner first.
+func (f *FileSystemGameStore) GetBalances() ([]Balance, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return CalculateBalances(f.ledger), nil
+}
+
+// CalculateBalances adds up the ledger into each player's balance, biggest
+// winner first.
+func CalculateBalances(ledger []LedgerEntry) []Balance {
+	var balances []Balance
+	index := map[string]int{}
+
+	add := func(player string, amount int) {
+		i, ok := index[playerKey(player)]
+		if !ok {
+			i = len(balances)
+			index[playerKey(player)] = i
+			balances = append(balances, Balance{Player: player})
+		}
+		balances[i].Balance += amount
+	}
+
+	for _, entry := range ledger {
+		switch entry.Kind {
+		case BuyIn, Rebuy:
+			add(entry.Player, -entry.Amount)
+		case CashOut:
+			add(entry.Player, entry.Amount)
+		case Payment:
+			add(entry.Player, entry.Amount)
+			add(entry.To, -entry.Amount)
+		}
+	}
+
+	sort.SliceStable(balances, func(i, j int) bool {
+		return balances[i].Balance > balances[j].Balance
+	})
+
+	return balances
+}
+
+// maxExactSettle is the most players with debts SettleUp finds the fewest
+// transfers for, it falls back to a greedy settlement above it.
+const maxExactSettle = 16
+
+// SettleUp returns the fewest transfers that clear every balance. Balances
+// that don't add up to zero, because a game's cash-outs don't match what was
+// put in, can't be settled.
+func SettleUp(balances []Balance) ([]Transfer, error) {
+	var open []Balance
+	total := 0
+
+	for _, balance := range balances {
+		if balance.Balance != 0 {
+			open = append(open, balance)
+			total += balance.Balance
+		}
+	}
+
+	if total != 0 {
+		return nil, fmt.Errorf("%w: balances are out by %d", ErrLedgerUnbalanced, total)
+	}
+
+	if len(open) > maxExactSettle {
+		return settleGreedily(open), nil
+	}
+
+	transfers := []Transfer{}
+	for _, group := range zeroSumGroups(open) {
+		transfers = append(transfers, settleGreedily(group)...)
+	}
+	return transfers, nil
+}
+
+// zeroSumGroups splits balances that add up to zero into as many groups that
+// each add up to zero as it can. A group of n players settles in n-1
+// transfers, so the most groups gives the fewest transfers.
+func zeroSumGroups(balances []Balance) [][]Balance {
+	n := len(balances)
+	full := 1<<n - 1
+	sums := make([]int, full+1)
+	groups := make([]int, full+1)
+
+	for mask := 1; mask <= full; mask++ {
+		for i := 0; i < n; i++ {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+
+			rest := mask &^ (1 << i)
+			sums[mask] = sums[rest] + balances[i].Balance
+
+			if groups[rest] > groups[mask] {
+				groups[mask] = groups[rest]
+			}
+		}
+
+		if sums[mask] == 0 {
+			groups[mask]++
+		}
+	}
+
+	var split [][]Balance
+	var group []Balance
+
+	for mask := full; mask != 0; {
+		for i := 0; i < n; i++ {
+			rest := mask &^ (1 << i)
+			closes := 0
+			if sums[mask] == 0 {
+				closes = 1
+			}
+
+			if mask&(1<<i) != 0 && groups[rest]+closes == groups[mask] {
+				group = append(group, balances[i])
+				mask = rest
+				break
+			}
+		}
+
+		if sums[mask] == 0 {
+			split = append(split, group)
+			group = nil
+		}
+	}
+
+	return split
+}
+
+// settleGreedily pays the biggest debt to the biggest creditor until every
+// balance is cleared.
+func settleGreedily(balances []Balance) []Transfer {
+	open := make([]Balance, len(balances))
+	copy(open, balances)
+
+	var transfers []Transfer
+
+	for {
+		sort.SliceStable(open, func(i, j int) bool {
+			return open[i].Balance > open[j].Balance
+		})
+
+		creditor, debtor := &open[0], &open[len(open)-1]
+
+		if creditor.Balance == 0 {
+			return transfers
+		}
+
+		amount := min(creditor.Balance, -debtor.Balance)
+		transfers = append(transfers, Transfer{From: debtor.Player, To: creditor.Player, Amount: amount})
+
+		creditor.Balance -= amount
+		debtor.Balance += amount
+	}
+}
@@ -0,0 +1,274 @@
+package poker_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func TestSettleUp(t *testing.T) {
+	t.Run("pays the losers' debts to the winners", func(t *testing.T) {
+		transfers, err := poker.SettleUp([]poker.Balance{{"Chris", 30}, {"Cleo", -10}, {"Ruth", -20}})
+		poker.AssertNoError(t, err)
+
+		assertSettlesUp(t, transfers, map[string]int{"Chris": 30, "Cleo": -10, "Ruth": -20})
+
+		if len(transfers) != 2 {
+			t.Errorf("got %d transfers want %d, %+v", len(transfers), 2, transfers)
+		}
+	})
+
+	t.Run("finds fewer transfers than paying the biggest debt first", func(t *testing.T) {
+		balances := map[string]int{"A": -9, "B": 7, "C": -2, "D": 5, "E": 6, "F": -7}
+
+		transfers, err := poker.SettleUp([]poker.Balance{{"A", -9}, {"B", 7}, {"C", -2}, {"D", 5}, {"E", 6}, {"F", -7}})
+		poker.AssertNoError(t, err)
+
+		assertSettlesUp(t, transfers, balances)
+
+		if len(transfers) != 4 {
+			t.Errorf("got %d transfers want %d, %+v", len(transfers), 4, transfers)
+		}
+	})
+
+	t.Run("has nothing to do when everyone is square", func(t *testing.T) {
+		transfers, err := poker.SettleUp([]poker.Balance{{"Chris", 0}})
+		poker.AssertNoError(t, err)
+
+		if len(transfers) != 0 {
+			t.Errorf("got transfers %+v want none", transfers)
+		}
+	})
+
+	t.Run("refuses balances that don't add up", func(t *testing.T) {
+		_, err := poker.SettleUp([]poker.Balance{{"Chris", 30}, {"Cleo", -10}})
+
+		if !errors.Is(err, poker.ErrLedgerUnbalanced) {
+			t.Errorf("got error %v want %v", err, poker.ErrLedgerUnbalanced)
+		}
+	})
+}
+
+func TestFileSystemGameStoreLedger(t *testing.T) {
+	t.Run("keeps running balances across games and payments", func(t *testing.T) {
+		store := mustMakeLedgerStore(t)
+
+		mustRecordLedgerEntry(t, store, poker.LedgerEntry{GameID: 1, Kind: poker.BuyIn, Player: "Chris", Amount: 20})
+		mustRecordLedgerEntry(t, store, poker.LedgerEntry{GameID: 1, Kind: poker.BuyIn, Player: "Cleo", Amount: 20})
+		mustRecordLedgerEntry(t, store, poker.LedgerEntry{GameID: 1, Kind: poker.Rebuy, Player: "Cleo", Amount: 20})
+		mustRecordLedgerEntry(t, store, poker.LedgerEntry{GameID: 1, Kind: poker.CashOut, Player: "chris", Amount: 60})
+		mustRecordLedgerEntry(t, store, poker.LedgerEntry{Kind: poker.Payment, Player: "Cleo", To: "Chris", Amount: 15})
+
+		balances, err := store.GetBalances()
+		poker.AssertNoError(t, err)
+
+		want := []poker.Balance{{"Chris", 25}, {"Cleo", -25}}
+
+		if !reflect.DeepEqual(balances, want) {
+			t.Errorf("got balances %+v want %+v", balances, want)
+		}
+	})
+
+	t.Run("refuses entries for a game that wasn't recorded", func(t *testing.T) {
+		store := mustMakeLedgerStore(t)
+
+		_, err := store.RecordLedgerEntry(poker.LedgerEntry{GameID: 7, Kind: poker.BuyIn, Player: "Chris", Amount: 20})
+
+		if !errors.Is(err, poker.ErrInvalidLedgerEntry) || !errors.Is(err, poker.ErrGameNotFound) {
+			t.Errorf("got error %v want %v", err, poker.ErrGameNotFound)
+		}
+	})
+
+	t.Run("takes buy-ins for the games being played", func(t *testing.T) {
+		store := mustMakeLedgerStore(t)
+		atTable := mustOpenGame(t, store)
+		online := mustOpenGame(t, store)
+
+		mustRecordLedgerEntry(t, store, poker.LedgerEntry{GameID: atTable, Kind: poker.BuyIn, Player: "Chris", Amount: 20})
+		mustRecordLedgerEntry(t, store, poker.LedgerEntry{GameID: online, Kind: poker.BuyIn, Player: "Ruth", Amount: 10})
+
+		if recorded := mustRecordGame(t, store, poker.GameRecord{ID: online, Winner: "Ruth"}); recorded.ID != online {
+			t.Errorf("got game id %d want the id it started with, %d", recorded.ID, online)
+		}
+
+		if recorded := mustRecordGame(t, store, poker.GameRecord{ID: atTable, Winner: "Chris"}); recorded.ID != atTable {
+			t.Errorf("got game id %d want the id it started with, %d", recorded.ID, atTable)
+		}
+	})
+
+	t.Run("refuses entries for a game that hasn't started", func(t *testing.T) {
+		store := mustMakeLedgerStore(t)
+
+		_, err := store.RecordLedgerEntry(poker.LedgerEntry{GameID: 2, Kind: poker.BuyIn, Player: "Chris", Amount: 20})
+		assertErrorIs(t, err, poker.ErrGameNotFound)
+	})
+
+	t.Run("refuses entries without an amount", func(t *testing.T) {
+		store := mustMakeLedgerStore(t)
+
+		_, err := store.RecordLedgerEntry(poker.LedgerEntry{GameID: 1, Kind: poker.CashOut, Player: "Chris"})
+
+		if !errors.Is(err, poker.ErrInvalidLedgerEntry) {
+			t.Errorf("got error %v want %v", err, poker.ErrInvalidLedgerEntry)
+		}
+	})
+
+	t.Run("keeps the ledger and the games across reopening the file", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+
+		store, closeStore, err := poker.FileSystemGameStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		mustRecordGame(t, store, poker.GameRecord{Winner: "Chris"})
+		mustRecordLedgerEntry(t, store, poker.LedgerEntry{GameID: 1, Kind: poker.BuyIn, Player: "Chris", Amount: 20})
+		mustRecordGame(t, store, poker.GameRecord{Winner: "Cleo"})
+		closeStore()
+
+		reopened, closeReopened, err := poker.FileSystemGameStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeReopened()
+
+		ledger, err := reopened.GetLedger()
+		poker.AssertNoError(t, err)
+
+		if len(ledger) != 1 || ledger[0].ID != 1 || ledger[0].Amount != 20 {
+			t.Errorf("got ledger %+v want Chris's buy-in", ledger)
+		}
+
+		if games, _ := reopened.GetGames(); len(games) != 2 {
+			t.Errorf("got %d games want %d", len(games), 2)
+		}
+	})
+}
+
+func TestLedgerOverHTTP(t *testing.T) {
+	games := mustMakeLedgerStore(t)
+	server, _ := poker.NewPlayerServer(&poker.StubPlayerStore{}, games, dummyGame)
+
+	for _, entry := range []poker.LedgerEntry{
+		{GameID: 1, Kind: poker.BuyIn, Player: "Chris", Amount: 20},
+		{GameID: 1, Kind: poker.BuyIn, Player: "Cleo", Amount: 20},
+		{GameID: 1, Kind: poker.CashOut, Player: "Cleo", Amount: 40},
+	} {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewPostLedgerEntryRequest(entry))
+		assertStatus(t, response, http.StatusCreated)
+	}
+
+	t.Run("POST /ledger returns 400 for a bad entry", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewPostLedgerEntryRequest(poker.LedgerEntry{Kind: "bribe", Player: "Chris", Amount: 5}))
+
+		assertStatus(t, response, http.StatusBadRequest)
+	})
+
+	t.Run("GET /ledger lists a game's entries", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetLedgerRequest("game=1"))
+
+		var ledger []poker.LedgerEntry
+		decodeJSON(t, response, &ledger)
+
+		if len(ledger) != 3 {
+			t.Errorf("got %d entries want %d", len(ledger), 3)
+		}
+	})
+
+	t.Run("GET /balances returns each player's balance", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetBalancesRequest())
+
+		var balances []poker.Balance
+		decodeJSON(t, response, &balances)
+
+		want := []poker.Balance{{"Cleo", 20}, {"Chris", -20}}
+		if !reflect.DeepEqual(balances, want) {
+			t.Errorf("got balances %+v want %+v", balances, want)
+		}
+	})
+
+	t.Run("GET /settle-up returns the transfers", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewSettleUpRequest())
+
+		var transfers []poker.Transfer
+		decodeJSON(t, response, &transfers)
+
+		want := []poker.Transfer{{From: "Chris", To: "Cleo", Amount: 20}}
+		if !reflect.DeepEqual(transfers, want) {
+			t.Errorf("got transfers %+v want %+v", transfers, want)
+		}
+	})
+
+	t.Run("GET /settle-up returns 409 while a game doesn't balance", func(t *testing.T) {
+		server.ServeHTTP(httptest.NewRecorder(), poker.NewPostLedgerEntryRequest(poker.LedgerEntry{GameID: 1, Kind: poker.Rebuy, Player: "Chris", Amount: 5}))
+
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewSettleUpRequest())
+
+		assertStatus(t, response, http.StatusConflict)
+	})
+
+	t.Run("returns 501 when the game store keeps no ledger", func(t *testing.T) {
+		server, _ := poker.NewPlayerServer(&poker.StubPlayerStore{}, &poker.StubGameStore{}, dummyGame)
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetBalancesRequest())
+
+		assertStatus(t, response, http.StatusNotImplemented)
+	})
+}
+
+func mustMakeLedgerStore(t *testing.T) *poker.FileSystemGameStore {
+	t.Helper()
+
+	store := mustMakeGameStore(t)
+	mustRecordGame(t, store, poker.GameRecord{Winner: "Chris"})
+
+	return store
+}
+
+func mustOpenGame(t *testing.T, store poker.GameOpener) int {
+	t.Helper()
+
+	id, err := store.OpenGame()
+	poker.AssertNoError(t, err)
+
+	return id
+}
+
+func mustRecordLedgerEntry(t *testing.T, store poker.LedgerStore, entry poker.LedgerEntry) {
+	t.Helper()
+
+	_, err := store.RecordLedgerEntry(entry)
+	poker.AssertNoError(t, err)
+}
+
+func decodeJSON(t *testing.T, response *httptest.ResponseRecorder, into interface{}) {
+	t.Helper()
+
+	assertStatus(t, response, http.StatusOK)
+
+	if err := json.NewDecoder(response.Body).Decode(into); err != nil {
+		t.Fatalf("could not parse response %q, %v", response.Body.String(), err)
+	}
+}
+
+func assertSettlesUp(t testing.TB, transfers []poker.Transfer, balances map[string]int) {
+	t.Helper()
+
+	for _, transfer := range transfers {
+		balances[transfer.From] += transfer.Amount
+		balances[transfer.To] -= transfer.Amount
+	}
+
+	for player, balance := range balances {
+		if balance != 0 {
+			t.Errorf("%s is left with %d after %+v", player, balance, transfers)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package poker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const currentSchemaVersion = 6
+
+// Migration upgrades a raw player database from version From to From+1.
+type Migration struct {
+	From        int
+	Description string
+	Migrate     func(data []byte) ([]byte, error)
+}
+
+var migrations = map[int]Migration{
+	1: {
+		From:        1,
+		Description: "wrap the bare player list in a versioned envelope",
+		Migrate:     wrapPlayersInEnvelope,
+	},
+	2: {
+		From:        2,
+		Description: "move the players into a first season named " + defaultSeasonName,
+		Migrate:     movePlayersIntoDefaultSeason,
+	},
+	3: {
+		From:        3,
+		Description: "add player aliases and fold together players whose names differ only by case or whitespace",
+		Migrate:     foldDuplicatePlayers,
+	},
+	4: {
+		From:        4,
+		Description: "add a log of the corrections made to players",
+		Migrate:     addChangeLog,
+	},
+	5: {
+		From:        5,
+		Description: "turn the log of corrections into a numbered audit log of every change",
+		Migrate:     numberAuditLog,
+	},
+}
+
+// MigrationReport describes the migrations applied, or in a dry run the
+// migrations that would be applied, to a player database.
+type MigrationReport struct {
+	Path        string
+	FromVersion int
+	ToVersion   int
+	Steps       []Migration
+}
+
+func (r MigrationReport) String() string {
+	if len(r.Steps) == 0 {
+		return fmt.Sprintf("%s is up to date at version %d", r.Path, r.ToVersion)
+	}
+
+	var report strings.Builder
+	fmt.Fprintf(&report, "%s: version %d -> %d", r.Path, r.FromVersion, r.ToVersion)
+	for _, step := range r.Steps {
+		fmt.Fprintf(&report, "\n  %d -> %d: %s", step.From, step.From+1, step.Description)
+	}
+	return report.String()
+}
+
+// MigratePlayerDBFile upgrades the database at path to the current schema
+// version, keeping the old file as a backup. With dryRun set the file is left
+// untouched and the report lists what would change.
+func MigratePlayerDBFile(path string, dryRun bool, options ...FileSystemOption) (MigrationReport, error) {
+	opts, err := applyFileOptions(options)
+
+	if err != nil {
+		return MigrationReport{}, err
+	}
+
+	lock, err := openFileLock(path)
+
+	if err != nil {
+		return MigrationReport{}, err
+	}
+	defer lock.Close()
+
+	if err := lock.Lock(); err != nil {
+		return MigrationReport{}, err
+	}
+	defer lock.Unlock()
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return MigrationReport{}, fmt.Errorf("problem reading %s, %v", path, err)
+	}
+
+	if data, err = opts.cipher.open(data); err != nil {
+		return MigrationReport{}, fmt.Errorf("problem reading %s, %w", path, err)
+	}
+
+	migrated, report, err := migratePlayerDB(data)
+	report.Path = path
+
+	if err != nil || dryRun || len(report.Steps) == 0 {
+		return report, err
+	}
+
+	if migrated, err = opts.cipher.seal(migrated); err != nil {
+		return report, err
+	}
+
+	if err := keepBackup(path); err != nil {
+		return report, err
+	}
+
+	return report, writeFileAtomically(path, migrated)
+}
+
+func decodePlayerDB(data []byte) (playerDB, MigrationReport, error) {
+	migrated, report, err := migratePlayerDB(data)
+
+	if err != nil {
+		return playerDB{}, report, err
+	}
+
+	var db playerDB
+	if err := json.Unmarshal(migrated, &db); err != nil {
+		return playerDB{}, report, fmt.Errorf("problem parsing league, %v", err)
+	}
+
+	if len(db.Seasons) == 0 {
+		return playerDB{}, report, errors.New("problem parsing league, no seasons")
+	}
+
+	if db.Aliases == nil {
+		db.Aliases = map[string]string{}
+	}
+
+	if db.Audit == nil {
+		db.Audit = []auditRecord{}
+	}
+
+	return db, report, nil
+}
+
+func migratePlayerDB(data []byte) ([]byte, MigrationReport, error) {
+	version, err := schemaVersion(data)
+
+	report := MigrationReport{FromVersion: version, ToVersion: version}
+
+	if err != nil {
+		return nil, report, err
+	}
+
+	if version > currentSchemaVersion {
+		return nil, report, fmt.Errorf("database version %d is newer than supported version %d", version, currentSchemaVersion)
+	}
+
+	for report.ToVersion < currentSchemaVersion {
+		step, ok := migrations[report.ToVersion]
+
+		if !ok {
+			return nil, report, fmt.Errorf("no migration from database version %d", report.ToVersion)
+		}
+
+		data, err = step.Migrate(data)
+
+		if err != nil {
+			return nil, report, fmt.Errorf("problem migrating database from version %d, %v", step.From, err)
+		}
+
+		report.Steps = append(report.Steps, step)
+		report.ToVersion++
+	}
+
+	return data, report, nil
+}
+
+func schemaVersion(data []byte) (int, error) {
+	trimmed := bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		return 1, nil
+	}
+
+	var envelope struct {
+		Version int `json:"version"`
+	}
+
+	if err := json.Unmarshal(trimmed, &envelope); err != nil {
+		return 0, fmt.Errorf("problem parsing league, %v", err)
+	}
+
+	if envelope.Version < 1 {
+		return 0, errors.New("problem parsing league, missing schema version")
+	}
+
+	return envelope.Version, nil
+}
+
+func wrapPlayersInEnvelope(data []byte) ([]byte, error) {
+	var players json.RawMessage
+	if err := json.Unmarshal(data, &players); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(struct {
+		Version int             `json:"version"`
+		Players json.RawMessage `json:"players"`
+	}{2, players})
+}
+
+func movePlayersIntoDefaultSeason(data []byte) ([]byte, error) {
+	var v2 struct {
+		Players json.RawMessage `json:"players"`
+	}
+
+	if err := json.Unmarshal(data, &v2); err != nil {
+		return nil, err
+	}
+
+	if len(v2.Players) == 0 || string(v2.Players) == "null" {
+		v2.Players = json.RawMessage("[]")
+	}
+
+	type season struct {
+		Name    string          `json:"name"`
+		Players json.RawMessage `json:"players"`
+	}
+
+	return json.Marshal(struct {
+		Version int      `json:"version"`
+		Seasons []season `json:"seasons"`
+	}{3, []season{{defaultSeasonName, v2.Players}}})
+}
+
+func foldDuplicatePlayers(data []byte) ([]byte, error) {
+	var db map[string]json.RawMessage
+	if err := json.Unmarshal(data, &db); err != nil {
+		return nil, err
+	}
+
+	var seasons []map[string]json.RawMessage
+	if err := json.Unmarshal(db["seasons"], &seasons); err != nil {
+		return nil, err
+	}
+
+	type player struct {
+		Name string
+		Wins int
+	}
+
+	for _, season := range seasons {
+		var players []player
+		if err := json.Unmarshal(season["players"], &players); err != nil {
+			return nil, err
+		}
+
+		folded := []player{}
+		seen := map[string]int{}
+		for _, p := range players {
+			if i, ok := seen[playerKey(p.Name)]; ok {
+				folded[i].Wins += p.Wins
+				continue
+			}
+			seen[playerKey(p.Name)] = len(folded)
+			folded = append(folded, player{NormalisePlayerName(p.Name), p.Wins})
+		}
+
+		var err error
+		if season["players"], err = json.Marshal(folded); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if db["seasons"], err = json.Marshal(seasons); err != nil {
+		return nil, err
+	}
+
+	db["version"] = json.RawMessage("4")
+	db["aliases"] = json.RawMessage("{}")
+	return json.Marshal(db)
+}
+
+func addChangeLog(data []byte) ([]byte, error) {
+	var db map[string]json.RawMessage
+	if err := json.Unmarshal(data, &db); err != nil {
+		return nil, err
+	}
+
+	db["version"] = json.RawMessage("5")
+	db["changes"] = json.RawMessage("[]")
+	return json.Marshal(db)
+}
+
+func numberAuditLog(data []byte) ([]byte, error) {
+	var db map[string]json.RawMessage
+	if err := json.Unmarshal(data, &db); err != nil {
+		return nil, err
+	}
+
+	audit := []map[string]json.RawMessage{}
+	if changes, ok := db["changes"]; ok {
+		if err := json.Unmarshal(changes, &audit); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, entry := range audit {
+		entry["id"] = json.RawMessage(strconv.Itoa(i + 1))
+	}
+
+	var err error
+	if db["audit"], err = json.Marshal(audit); err != nil {
+		return nil, err
+	}
+
+	delete(db, "changes")
+	db["version"] = json.RawMessage("6")
+	return json.Marshal(db)
+}
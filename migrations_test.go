@@ -0,0 +1,155 @@
+package poker_test
+
+import (
+	"strings"
+	"testing"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+const bareArrayDB = `[{"Name": "Cleo", "Wins": 10}]`
+
+// latestSchemaVersion is the version every migration test expects to end at.
+const latestSchemaVersion = 6
+
+func TestMigratePlayerDBFile(t *testing.T) {
+	t.Run("dry run reports the migrations without changing the file", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, bareArrayDB)
+		defer cleanDatabase()
+
+		report, err := poker.MigratePlayerDBFile(database.Name(), true)
+		poker.AssertNoError(t, err)
+
+		assertMigratedVersions(t, report, 1, latestSchemaVersion)
+
+		if len(report.Steps) != latestSchemaVersion-1 {
+			t.Fatalf("got %d migration steps want %d", len(report.Steps), latestSchemaVersion-1)
+		}
+
+		for _, step := range []string{"1 -> 2", "2 -> 3", "3 -> 4", "4 -> 5", "5 -> 6"} {
+			if !strings.Contains(report.String(), step) {
+				t.Errorf("report %q does not describe the step %s", report, step)
+			}
+		}
+
+		if got := readFile(t, database.Name()); got != bareArrayDB {
+			t.Errorf("dry run changed the file to %q", got)
+		}
+	})
+
+	t.Run("upgrades a version 1 file and keeps a backup", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, bareArrayDB)
+		defer cleanDatabase()
+
+		report, err := poker.MigratePlayerDBFile(database.Name(), false)
+		poker.AssertNoError(t, err)
+		assertMigratedVersions(t, report, 1, latestSchemaVersion)
+
+		store, closeStore, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeStore()
+
+		if store.Migrated() != nil {
+			t.Errorf("expected the file to be up to date, got %v", store.Migrated())
+		}
+		poker.AssertScore(t, store, "Cleo", 10)
+
+		if backup := readFile(t, database.Name()+".bak"); backup != bareArrayDB {
+			t.Errorf("got backup %q want %q", backup, bareArrayDB)
+		}
+	})
+
+	t.Run("moves version 2 players into the default season", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `{"version":2,"players":[{"Name":"Cleo","Wins":10}]}`)
+		defer cleanDatabase()
+
+		report, err := poker.MigratePlayerDBFile(database.Name(), false)
+		poker.AssertNoError(t, err)
+		assertMigratedVersions(t, report, 2, latestSchemaVersion)
+
+		store, closeStore, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeStore()
+
+		score, err := store.GetSeasonPlayerScore("default", "Cleo")
+		poker.AssertNoError(t, err)
+		assertScoreEquals(t, score, 10)
+	})
+
+	t.Run("folds version 3 players that differ only by case or spacing", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `{"version":3,"seasons":[{"name":"default","players":[
+			{"Name":"Chris","Wins":3},{"Name":"chris","Wins":2},{"Name":"Chris ","Wins":1},{"Name":"Cleo","Wins":1}]}]}`)
+		defer cleanDatabase()
+
+		report, err := poker.MigratePlayerDBFile(database.Name(), false)
+		poker.AssertNoError(t, err)
+		assertMigratedVersions(t, report, 3, latestSchemaVersion)
+
+		store, closeStore, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeStore()
+
+		want := []poker.Player{
+			{"Chris", 6},
+			{"Cleo", 1},
+		}
+		poker.AssertLeague(t, poker.MustGetLeague(t, store), want)
+	})
+
+	t.Run("leaves an up to date file alone", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `{"version":6,"seasons":[{"name":"default","players":[]}],"aliases":{},"audit":[]}`)
+		defer cleanDatabase()
+
+		report, err := poker.MigratePlayerDBFile(database.Name(), false)
+		poker.AssertNoError(t, err)
+
+		assertMigratedVersions(t, report, latestSchemaVersion, latestSchemaVersion)
+
+		if len(report.Steps) != 0 {
+			t.Errorf("got %d migration steps want none", len(report.Steps))
+		}
+	})
+
+	t.Run("refuses a file from a newer version", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `{"version":99,"players":[]}`)
+		defer cleanDatabase()
+
+		_, err := poker.MigratePlayerDBFile(database.Name(), true)
+
+		if err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+}
+
+func TestFileSystemPlayerStoreMigratesOnOpen(t *testing.T) {
+	database, cleanDatabase := createTempFile(t, bareArrayDB)
+	defer cleanDatabase()
+
+	store, closeStore, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+	poker.AssertNoError(t, err)
+	defer closeStore()
+
+	poker.AssertScore(t, store, "Cleo", 10)
+
+	migrated := store.Migrated()
+	if migrated == nil {
+		t.Fatal("expected the store to report a migration")
+	}
+	assertMigratedVersions(t, *migrated, 1, latestSchemaVersion)
+
+	report, err := poker.MigratePlayerDBFile(database.Name(), true)
+	poker.AssertNoError(t, err)
+
+	if len(report.Steps) != 0 {
+		t.Errorf("expected the file to have been upgraded on open, still needs %v", report)
+	}
+}
+
+func assertMigratedVersions(t testing.TB, report poker.MigrationReport, from, to int) {
+	t.Helper()
+
+	if report.FromVersion != from || report.ToVersion != to {
+		t.Errorf("got migration from %d to %d, want %d to %d", report.FromVersion, report.ToVersion, from, to)
+	}
+}
@@ -0,0 +1,149 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrPlayerExists   = errors.New("player already exists")
+	ErrReasonRequired = errors.New("a reason is required")
+	ErrInvalidScore   = errors.New("invalid score")
+)
+
+// PlayerAdminStore is implemented by player stores that let the league be
+// corrected by hand. Every change is kept in the audit log along with the
+// reason given for it.
+type PlayerAdminStore interface {
+	RenamePlayer(name, newName, reason string) error
+	DeletePlayer(name, reason string) error
+	SetPlayerScore(name string, wins int, reason string) error
+	AdjustPlayerScore(name string, delta int, reason string) error
+}
+
+// RenamePlayer renames a player in every season. Aliases of the player follow
+// them to the new name.
+func (f *FileSystemPlayerStore) RenamePlayer(name, newName, reason string) error {
+	if err := validReason(reason); err != nil {
+		return err
+	}
+
+	return f.update(func(db *playerDB) error {
+		from := db.resolve(name)
+		to := NormalisePlayerName(newName)
+
+		if err := db.rename(from, to); err != nil {
+			return err
+		}
+
+		wins := db.activeWins(to)
+		db.log(auditRecord{Action: renameAction, Player: from, To: to, Before: wins, After: wins, Reason: reason})
+		return nil
+	})
+}
+
+// DeletePlayer removes a player from the active season. Closed seasons keep
+// their standings.
+func (f *FileSystemPlayerStore) DeletePlayer(name, reason string) error {
+	if err := validReason(reason); err != nil {
+		return err
+	}
+
+	return f.update(func(db *playerDB) error {
+		player := db.resolve(name)
+		season := db.activeSeason()
+
+		if !season.has(player) {
+			return fmt.Errorf("%w: %s", ErrPlayerNotFound, player)
+		}
+
+		wins := season.wins(player)
+		season.setWins(player, 0)
+
+		db.log(auditRecord{Action: deleteAction, Player: player, Before: wins, After: 0, Reason: reason})
+		return nil
+	})
+}
+
+// SetPlayerScore sets a player's wins in the active season. Setting a score
+// of zero removes the player from the season.
+func (f *FileSystemPlayerStore) SetPlayerScore(name string, wins int, reason string) error {
+	if err := validReason(reason); err != nil {
+		return err
+	}
+
+	return f.update(func(db *playerDB) error {
+		return db.setScore(db.resolve(name), wins, reason)
+	})
+}
+
+// AdjustPlayerScore adds delta, which may be negative, to a player's wins in
+// the active season.
+func (f *FileSystemPlayerStore) AdjustPlayerScore(name string, delta int, reason string) error {
+	if err := validReason(reason); err != nil {
+		return err
+	}
+
+	return f.update(func(db *playerDB) error {
+		player := db.resolve(name)
+		return db.setScore(player, db.activeWins(player)+delta, reason)
+	})
+}
+
+func (db *playerDB) rename(from, to string) error {
+	if !db.hasPlayer(from) {
+		return fmt.Errorf("%w: %s", ErrPlayerNotFound, from)
+	}
+
+	if to == "" {
+		return fmt.Errorf("invalid player name %q", to)
+	}
+
+	if owner := db.resolve(to); playerKey(to) != playerKey(from) {
+		if db.hasPlayer(to) || (playerKey(owner) != playerKey(to) && playerKey(owner) != playerKey(from)) {
+			return fmt.Errorf("%w: %s, merge the players instead", ErrPlayerExists, to)
+		}
+	}
+
+	for i := range db.Seasons {
+		db.Seasons[i].rename(from, to)
+	}
+
+	for alias, player := range db.Aliases {
+		if playerKey(player) == playerKey(from) {
+			db.Aliases[alias] = to
+		}
+	}
+	delete(db.Aliases, playerKey(to))
+
+	return nil
+}
+
+func (db *playerDB) setScore(player string, wins int, reason string) error {
+	if wins < 0 {
+		return fmt.Errorf("%w: %s can't have %d wins", ErrInvalidScore, player, wins)
+	}
+
+	if player == "" {
+		return fmt.Errorf("invalid player name %q", player)
+	}
+
+	season := db.activeSeason()
+	before := season.wins(player)
+	season.setWins(player, wins)
+
+	db.log(auditRecord{Action: setScoreAction, Player: player, Before: before, After: wins, Reason: reason})
+	return nil
+}
+
+func (db *playerDB) activeWins(player string) int {
+	return db.activeSeason().wins(player)
+}
+
+func validReason(reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return ErrReasonRequired
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package poker_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func TestFileSystemPlayerStoreCorrections(t *testing.T) {
+	t.Run("renames a player in every season", func(t *testing.T) {
+		store := mustMakeSeasonedStore(t)
+		poker.MustRecordWin(t, store, "Cleo")
+
+		poker.AssertNoError(t, store.RenamePlayer("cleo", "Cleopatra", "full name"))
+
+		poker.AssertScore(t, store, "Cleopatra", 1)
+		poker.AssertScore(t, store, "Cleo", 0)
+		assertSeasonScore(t, store, "default", "Cleopatra", 10)
+	})
+
+	t.Run("can fix the capitalisation of a name", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		poker.AssertNoError(t, store.RenamePlayer("Chris", "CHRIS", "shouty"))
+
+		want := []poker.Player{
+			{"CHRIS", 33},
+			{"Cleo", 10},
+		}
+		poker.AssertLeague(t, poker.MustGetLeague(t, store), want)
+	})
+
+	t.Run("refuses to rename a player to someone who already has wins", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		err := store.RenamePlayer("Chris", "Cleo", "typo")
+
+		if !errors.Is(err, poker.ErrPlayerExists) {
+			t.Errorf("got error %v want %v", err, poker.ErrPlayerExists)
+		}
+	})
+
+	t.Run("deletes a player from the active season", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		poker.AssertNoError(t, store.DeletePlayer("Cleo", "never played"))
+
+		want := []poker.Player{
+			{"Chris", 33},
+		}
+		poker.AssertLeague(t, poker.MustGetLeague(t, store), want)
+	})
+
+	t.Run("sets and adjusts scores", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		poker.AssertNoError(t, store.SetPlayerScore("Chris", 20, "recount"))
+		poker.AssertScore(t, store, "Chris", 20)
+
+		poker.AssertNoError(t, store.AdjustPlayerScore("Chris", -2, "double counted"))
+		poker.AssertScore(t, store, "Chris", 18)
+
+		poker.AssertNoError(t, store.AdjustPlayerScore("Tom", 1, "missed a win"))
+		poker.AssertScore(t, store, "Tom", 1)
+	})
+
+	t.Run("refuses a negative score", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		err := store.AdjustPlayerScore("Cleo", -11, "too many")
+
+		if !errors.Is(err, poker.ErrInvalidScore) {
+			t.Errorf("got error %v want %v", err, poker.ErrInvalidScore)
+		}
+		poker.AssertScore(t, store, "Cleo", 10)
+	})
+
+	t.Run("refuses a change without a reason", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		err := store.DeletePlayer("Cleo", " ")
+
+		if !errors.Is(err, poker.ErrReasonRequired) {
+			t.Errorf("got error %v want %v", err, poker.ErrReasonRequired)
+		}
+	})
+
+	t.Run("keeps every change and its reason across reopening the file", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `[{"Name": "Chirs", "Wins": 3}]`)
+		defer cleanDatabase()
+
+		store, closeStore, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		poker.AssertNoError(t, store.RenamePlayer("Chirs", "Chris", "typo in the cli"))
+		poker.AssertNoError(t, store.SetPlayerScore("Chris", 4, "missed a win"))
+		closeStore()
+
+		reopened, closeReopened, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeReopened()
+
+		changes, err := reopened.GetPlayerChanges("Chris")
+		poker.AssertNoError(t, err)
+
+		if len(changes) != 2 {
+			t.Fatalf("got %d changes want %d, %+v", len(changes), 2, changes)
+		}
+
+		rename, set := changes[0], changes[1]
+
+		if rename.Action != "rename" || rename.Player != "Chirs" || rename.To != "Chris" || rename.Reason != "typo in the cli" {
+			t.Errorf("got rename change %+v", rename)
+		}
+
+		if set.Action != "set-score" || set.Before != 3 || set.After != 4 || set.Reason != "missed a win" {
+			t.Errorf("got set score change %+v", set)
+		}
+	})
+}
+
+func TestCorrectionsOverHTTP(t *testing.T) {
+	store := mustMakeAliasStore(t)
+	server := mustMakePlayerServer(t, store, dummyGame)
+	server.AdminToken = adminToken
+
+	t.Run("POST /players/{name}/rename/{newName} renames a player", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewRenamePlayerRequest("Cleo", "Cleopatra", "full name", adminToken))
+
+		assertStatus(t, response, http.StatusAccepted)
+		poker.AssertScore(t, store, "Cleopatra", 10)
+	})
+
+	t.Run("PUT /players/{name}/score/{wins} sets a score", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewSetScoreRequest("Chris", 30, "recount", adminToken))
+
+		assertStatus(t, response, http.StatusAccepted)
+		poker.AssertScore(t, store, "Chris", 30)
+	})
+
+	t.Run("POST /players/{name}/score/{delta} adjusts a score", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewAdjustScoreRequest("Chris", -1, "double counted", adminToken))
+
+		assertStatus(t, response, http.StatusAccepted)
+		poker.AssertScore(t, store, "Chris", 29)
+	})
+
+	t.Run("DELETE /players/{name} deletes a player", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewDeletePlayerRequest("Cleopatra", "left the club", adminToken))
+
+		assertStatus(t, response, http.StatusAccepted)
+		poker.AssertScore(t, store, "Cleopatra", 0)
+	})
+
+	t.Run("GET /players/{name}/changes lists the changes", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetPlayerChangesRequest("Chris"))
+
+		assertStatus(t, response, http.StatusOK)
+		poker.AssertContentType(t, response, "application/json")
+
+		var changes []poker.AuditEntry
+		if err := json.NewDecoder(response.Body).Decode(&changes); err != nil {
+			t.Fatalf("could not parse changes, %v", err)
+		}
+
+		if len(changes) != 2 {
+			t.Errorf("got %d changes want %d", len(changes), 2)
+		}
+	})
+
+	t.Run("returns 400 without a reason", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewSetScoreRequest("Chris", 1, "", adminToken))
+
+		assertStatus(t, response, http.StatusBadRequest)
+		assertJSONError(t, response.Body, poker.ErrReasonRequired.Error())
+	})
+
+	t.Run("returns 404 for an unknown player", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewDeletePlayerRequest("Nobody", "cleanup", adminToken))
+
+		assertStatus(t, response, http.StatusNotFound)
+	})
+
+	t.Run("needs the admin token", func(t *testing.T) {
+		requests := []*http.Request{
+			poker.NewRenamePlayerRequest("Chris", "Christopher", "full name", ""),
+			poker.NewSetScoreRequest("Chris", 1, "recount", "wrong token"),
+			poker.NewAdjustScoreRequest("Chris", 1, "missed a win", ""),
+			poker.NewDeletePlayerRequest("Chris", "cleanup", ""),
+		}
+
+		for _, request := range requests {
+			response := httptest.NewRecorder()
+			server.ServeHTTP(response, request)
+
+			assertStatus(t, response, http.StatusUnauthorized)
+		}
+
+		poker.AssertScore(t, store, "Chris", 29)
+	})
+
+	t.Run("returns 501 when the store can't be corrected", func(t *testing.T) {
+		server := mustMakePlayerServer(t, &poker.StubPlayerStore{}, dummyGame)
+		server.AdminToken = adminToken
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewDeletePlayerRequest("Chris", "cleanup", adminToken))
+
+		assertStatus(t, response, http.StatusNotImplemented)
+	})
+}
@@ -0,0 +1,123 @@
+package poker
+
+import (
+	"maps"
+	"time"
+)
+
+const defaultSeasonName = "default"
+
+// playerDB is the on-disk envelope of the player database. Version 1 files
+// predate it and are a bare JSON array of players, see migrations.go for how
+// older files are upgraded.
+type playerDB struct {
+	Version int            `json:"version"`
+	Seasons []seasonRecord `json:"seasons"`
+
+	// Aliases maps the key of an alias to the name of the player it
+	// resolves to.
+	Aliases map[string]string `json:"aliases"`
+
+	// Audit logs the changes made to the database, oldest first, up to the
+	// store's KeepAudit.
+	Audit []auditRecord `json:"audit"`
+
+	// logged counts the entries added to Audit since the database was
+	// loaded or cloned, they're the ones stampAudit stamps.
+	logged int
+}
+
+// seasonRecord is one season of the league. The last season is the active
+// one, every season before it is closed.
+type seasonRecord struct {
+	Name      string     `json:"name"`
+	StartedAt time.Time  `json:"startedAt"`
+	ClosedAt  *time.Time `json:"closedAt,omitempty"`
+
+	// league holds the season's players, it's only changed through the
+	// methods in season_index.go and saved as the players in standings
+	// order.
+	league *leagueIndex
+}
+
+func newPlayerDB() playerDB {
+	return playerDB{
+		Version: currentSchemaVersion,
+		Seasons: []seasonRecord{
+			{Name: defaultSeasonName, StartedAt: time.Now().UTC(), league: newLeagueIndex(nil)},
+		},
+		Aliases: map[string]string{},
+		Audit:   []auditRecord{},
+	}
+}
+
+// resolve returns the name a player is recorded under, following aliases.
+func (db *playerDB) resolve(name string) string {
+	if player, ok := db.Aliases[playerKey(name)]; ok {
+		return player
+	}
+	return NormalisePlayerName(name)
+}
+
+func (db *playerDB) activeSeason() *seasonRecord {
+	return &db.Seasons[len(db.Seasons)-1]
+}
+
+func (db *playerDB) findSeason(name string) *seasonRecord {
+	for i := range db.Seasons {
+		if db.Seasons[i].Name == name {
+			return &db.Seasons[i]
+		}
+	}
+	return nil
+}
+
+// clone copies the database for a change to be made to it. The audit log is
+// only ever appended to, so rather than being copied it's shared: the entries
+// the change logs go past the end of the original's, and Undo copies the log
+// before it marks an entry undone. Only one change at a time may append to
+// the copies of a database, a copy used outside the store's write lock must
+// be clipped first.
+func (db playerDB) clone() playerDB {
+	copied := db
+	copied.Seasons = make([]seasonRecord, len(db.Seasons))
+	copied.Aliases = maps.Clone(db.Aliases)
+	copied.logged = 0
+
+	for i, season := range db.Seasons {
+		copied.Seasons[i] = season.clone()
+	}
+
+	return copied
+}
+
+func (s seasonRecord) clone() seasonRecord {
+	copied := s
+	copied.league = s.league.clone()
+
+	if s.ClosedAt != nil {
+		closedAt := *s.ClosedAt
+		copied.ClosedAt = &closedAt
+	}
+
+	return copied
+}
+
+// allTime adds up every player's wins across all seasons.
+func (db *playerDB) allTime() League {
+	var league League
+	places := map[string]int{}
+
+	for _, season := range db.Seasons {
+		for _, p := range season.standings() {
+			if i, ok := places[playerKey(p.Name)]; ok {
+				league[i].Wins += p.Wins
+			} else {
+				places[playerKey(p.Name)] = len(league)
+				league = append(league, p)
+			}
+		}
+	}
+
+	return league
+}
@@ -0,0 +1,180 @@
+package poker
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	errAdminDisabled         = errors.New("admin endpoints are disabled, start the server with an admin token")
+	errUnauthorised          = errors.New("a valid admin token is required")
+	errSnapshotsNotSupported = errors.New("this player store does not support snapshots")
+	errImportNotSupported    = errors.New("this player store does not support importing results")
+	errMetricsNotSupported   = errors.New("this player store is not measured, wrap it in a MetricsPlayerStore")
+)
+
+// requireAdmin lets a request through to admin only when it carries the
+// server's AdminToken as a bearer token. With no AdminToken set the admin
+// endpoints are turned off.
+func (p *PlayerServer) requireAdmin(admin http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if p.AdminToken == "" {
+			writeJSONError(w, http.StatusForbidden, errAdminDisabled)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(p.AdminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeJSONError(w, http.StatusUnauthorized, errUnauthorised)
+			return
+		}
+
+		admin(w, r)
+	}
+}
+
+// snapshotsHandler lists the snapshots kept, and takes a new one on POST.
+func (p *PlayerServer) snapshotsHandler(w http.ResponseWriter, r *http.Request) {
+	snapshots, ok := StoreAs[SnapshotStore](p.storeFor(r))
+
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, errSnapshotsNotSupported)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		kept, err := snapshots.GetSnapshots()
+
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.Header().Set("content-type", jsonContentType)
+		json.NewEncoder(w).Encode(kept)
+	case http.MethodPost:
+		snapshot, err := snapshots.TakeSnapshot()
+
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.Header().Set("content-type", jsonContentType)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(snapshot)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// restoreHandler replaces the live data with the snapshot POSTed to
+// /admin/snapshots/{name}/restore.
+func (p *PlayerServer) restoreHandler(w http.ResponseWriter, r *http.Request) {
+	snapshots, ok := StoreAs[SnapshotStore](p.storeFor(r))
+
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, errSnapshotsNotSupported)
+		return
+	}
+
+	name, action, _ := strings.Cut(r.URL.Path[len("/admin/snapshots/"):], "/")
+
+	if r.Method != http.MethodPost || action != "restore" {
+		http.NotFound(w, r)
+		return
+	}
+
+	err := snapshots.Restore(name)
+
+	switch {
+	case errors.Is(err, ErrSnapshotNotFound):
+		writeJSONError(w, http.StatusNotFound, err)
+	case err != nil:
+		writeJSONError(w, http.StatusInternalServerError, err)
+	default:
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// maxImportSize is the largest league file importHandler accepts.
+const maxImportSize = 32 << 20
+
+// importHandler merges the league uploaded as the multipart file field into
+// the store and returns the import report. The file is read as CSV or JSON by
+// its extension. The conflicts field says how to settle conflicts, and a
+// dry-run field of true only reports what would change.
+func (p *PlayerServer) importHandler(w http.ResponseWriter, r *http.Request) {
+	importer, ok := StoreAs[ImportStore](p.storeFor(r))
+
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, errImportNotSupported)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	file, header, err := r.FormFile("file")
+
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("problem reading the uploaded file, %v", err))
+		return
+	}
+	defer file.Close()
+
+	league, err := ReadLeague(file, strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), "."))
+
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.FormValue("dry-run"))
+	report, err := importer.ImportLeague(league, ImportOptions{
+		Conflicts: r.FormValue("conflicts"),
+		DryRun:    dryRun,
+		Reason:    "imported " + header.Filename,
+	})
+
+	switch {
+	case errors.Is(err, ErrInvalidImport), errors.Is(err, ErrUnknownConflict):
+		writeJSONError(w, http.StatusBadRequest, err)
+	case err != nil:
+		writeJSONError(w, http.StatusInternalServerError, err)
+	default:
+		w.Header().Set("content-type", jsonContentType)
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// metricsHandler returns the calls made to each method of the player store,
+// when it's wrapped in a MetricsPlayerStore.
+func (p *PlayerServer) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+
+	metrics, ok := StoreAs[MetricsStore](p.store)
+
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, errMetricsNotSupported)
+		return
+	}
+
+	w.Header().Set("content-type", jsonContentType)
+	json.NewEncoder(w).Encode(metrics.GetMetrics())
+}
@@ -0,0 +1,142 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrUnknownTieBreak = errors.New("unknown tie-break")
+
+// Ranking compares two players, returning a negative number when a ranks
+// above b, a positive number when b ranks above a and zero when they're level.
+type Ranking func(a, b PlayerStats) int
+
+func ByWins(a, b PlayerStats) int {
+	return b.Wins - a.Wins
+}
+
+func ByRating(a, b PlayerStats) int {
+	switch {
+	case a.Rating > b.Rating:
+		return -1
+	case a.Rating < b.Rating:
+		return 1
+	}
+	return 0
+}
+
+// FewestGames ranks the player who needed fewer games above the other.
+func FewestGames(a, b PlayerStats) int {
+	return a.GamesPlayed - b.GamesPlayed
+}
+
+// MostRecentWin ranks the player who won a game last above the other, and
+// anyone who has won a game above someone who hasn't.
+func MostRecentWin(a, b PlayerStats) int {
+	switch {
+	case a.LastWon == nil && b.LastWon == nil:
+		return 0
+	case b.LastWon == nil:
+		return -1
+	case a.LastWon == nil:
+		return 1
+	}
+	return b.LastWon.Compare(*a.LastWon)
+}
+
+func ByName(a, b PlayerStats) int {
+	return strings.Compare(a.Name, b.Name)
+}
+
+// TieBreaks are the tie-breaks that can be asked for by name.
+var TieBreaks = map[string]Ranking{
+	"fewest-games": FewestGames,
+	"recent-win":   MostRecentWin,
+	"name":         ByName,
+}
+
+// ParseTieBreaks returns the tie-breaks named in a comma separated list, in
+// the order they're given.
+func ParseTieBreaks(names string) ([]Ranking, error) {
+	var tieBreaks []Ranking
+
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+
+		if name == "" {
+			continue
+		}
+
+		tieBreak, ok := TieBreaks[name]
+
+		if !ok {
+			return nil, fmt.Errorf("%w %q, want fewest-games, recent-win or name", ErrUnknownTieBreak, name)
+		}
+
+		tieBreaks = append(tieBreaks, tieBreak)
+	}
+
+	return tieBreaks, nil
+}
+
+// RankStats orders stats by each ranking in turn and gives every player their
+// standard competition rank, so players level on every ranking share a rank
+// and the rank after them is skipped: 1, 2, 2, 4. Players who share a rank are
+// listed by name, so the order never changes between calls.
+func RankStats(stats []PlayerStats, rankings ...Ranking) {
+	compare := func(a, b PlayerStats) int {
+		for _, ranking := range rankings {
+			if c := ranking(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		if c := compare(stats[i], stats[j]); c != 0 {
+			return c < 0
+		}
+		return stats[i].Name < stats[j].Name
+	})
+
+	for i := range stats {
+		stats[i].Rank = i + 1
+
+		if i > 0 && compare(stats[i-1], stats[i]) == 0 {
+			stats[i].Rank = stats[i-1].Rank
+		}
+	}
+}
+
+// Ranks returns the standard competition rank of each player in a league
+// ordered by wins.
+func (l League) Ranks() []int {
+	ranks := make([]int, len(l))
+
+	for i := range l {
+		ranks[i] = i + 1
+
+		if i > 0 && l[i-1].Wins == l[i].Wins {
+			ranks[i] = ranks[i-1]
+		}
+	}
+
+	return ranks
+}
+
+// sortByWins orders the league by wins, and players on the same wins by name.
+// No two players share a name, so the order is the same however the league
+// started out.
+func (l League) sortByWins() {
+	sort.Slice(l, func(i, j int) bool { return ranksAbove(l[i], l[j]) })
+}
+
+func ranksAbove(a, b Player) bool {
+	if a.Wins != b.Wins {
+		return a.Wins > b.Wins
+	}
+	return a.Name < b.Name
+}
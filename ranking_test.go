@@ -0,0 +1,173 @@
+package poker_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func TestRankStats(t *testing.T) {
+	tuesday := time.Date(2026, time.February, 10, 20, 0, 0, 0, time.UTC)
+	wednesday := tuesday.AddDate(0, 0, 1)
+
+	league := func() []poker.PlayerStats {
+		return []poker.PlayerStats{
+			{Name: "Ruth", Wins: 2, GamesPlayed: 5, LastWon: &tuesday},
+			{Name: "Chris", Wins: 3, GamesPlayed: 9, LastWon: &tuesday},
+			{Name: "Cleo", Wins: 2, GamesPlayed: 3, LastWon: &wednesday},
+			{Name: "Tom", Wins: 1, GamesPlayed: 3, LastWon: &wednesday},
+			{Name: "Ann", Wins: 0, GamesPlayed: 1},
+		}
+	}
+
+	t.Run("players on the same wins share a rank", func(t *testing.T) {
+		stats := league()
+		poker.RankStats(stats, poker.ByWins)
+
+		assertRanks(t, stats, []string{"1 Chris", "2 Cleo", "2 Ruth", "4 Tom", "5 Ann"})
+	})
+
+	t.Run("breaks ties on fewer games played", func(t *testing.T) {
+		stats := league()
+		stats[0].GamesPlayed = 3
+		poker.RankStats(stats, poker.ByWins, poker.FewestGames)
+
+		assertRanks(t, stats, []string{"1 Chris", "2 Cleo", "2 Ruth", "4 Tom", "5 Ann"})
+
+		stats = league()
+		poker.RankStats(stats, poker.ByWins, poker.FewestGames)
+
+		assertRanks(t, stats, []string{"1 Chris", "2 Cleo", "3 Ruth", "4 Tom", "5 Ann"})
+	})
+
+	t.Run("breaks ties on the most recent win", func(t *testing.T) {
+		stats := league()
+		stats[2].LastWon = nil
+		poker.RankStats(stats, poker.ByWins, poker.MostRecentWin)
+
+		assertRanks(t, stats, []string{"1 Chris", "2 Ruth", "3 Cleo", "4 Tom", "5 Ann"})
+	})
+
+	t.Run("orders the same way whatever order the stats come in", func(t *testing.T) {
+		stats := league()
+		poker.RankStats(stats, poker.ByWins)
+
+		reversed := league()
+		for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+			reversed[i], reversed[j] = reversed[j], reversed[i]
+		}
+		poker.RankStats(reversed, poker.ByWins)
+
+		if !reflect.DeepEqual(stats, reversed) {
+			t.Errorf("got %+v and %+v want the same league", stats, reversed)
+		}
+	})
+}
+
+func TestParseTieBreaks(t *testing.T) {
+	tieBreaks, err := poker.ParseTieBreaks("recent-win, name")
+	poker.AssertNoError(t, err)
+
+	if len(tieBreaks) != 2 {
+		t.Errorf("got %d tie-breaks want %d", len(tieBreaks), 2)
+	}
+
+	if _, err := poker.ParseTieBreaks("name,height"); !errors.Is(err, poker.ErrUnknownTieBreak) {
+		t.Errorf("got error %v want %v", err, poker.ErrUnknownTieBreak)
+	}
+}
+
+func TestLeagueRanks(t *testing.T) {
+	league := poker.League{{"Chris", 3}, {"Cleo", 2}, {"Ruth", 2}, {"Tom", 1}}
+
+	if got, want := league.Ranks(), []int{1, 2, 2, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got ranks %v want %v", got, want)
+	}
+}
+
+func TestFileSystemPlayerStoreOrdersTiesByName(t *testing.T) {
+	database, cleanDatabase := createTempFile(t, `[
+      {"Name": "Ruth", "Wins": 10},
+      {"Name": "Chris", "Wins": 33},
+      {"Name": "Cleo", "Wins": 10}]`)
+	defer cleanDatabase()
+
+	store, err := poker.NewFileSystemPlayerStore(database)
+	poker.AssertNoError(t, err)
+
+	want := poker.League{{"Chris", 33}, {"Cleo", 10}, {"Ruth", 10}}
+
+	for i := 0; i < 10; i++ {
+		if got := poker.MustGetLeague(t, store); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got league %v want %v", got, want)
+		}
+	}
+}
+
+func TestRankedLeagueOverHTTP(t *testing.T) {
+	store := &poker.StubPlayerStore{League: []poker.Player{{"Chris", 3}, {"Ruth", 1}, {"Cleo", 1}}}
+	games := &poker.StubGameStore{Games: []poker.GameRecord{
+		statsGame(0, "Cleo", "Chris", "Cleo", "Ruth"),
+		statsGame(1, "Ruth", "Chris", "Ruth"),
+	}}
+	server, _ := poker.NewPlayerServer(store, games, dummyGame)
+
+	t.Run("GET /league ranks players level on wins together", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetLeagueRequest())
+
+		var stats []poker.PlayerStats
+		decodeJSON(t, response, &stats)
+
+		assertRanks(t, stats, []string{"1 Chris", "2 Cleo", "2 Ruth"})
+	})
+
+	t.Run("GET /league breaks ties with the server's tie-breaks", func(t *testing.T) {
+		server.TieBreaks = []poker.Ranking{poker.MostRecentWin}
+		defer func() { server.TieBreaks = nil }()
+
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetLeagueRequest())
+
+		var stats []poker.PlayerStats
+		decodeJSON(t, response, &stats)
+
+		assertRanks(t, stats, []string{"1 Chris", "2 Ruth", "3 Cleo"})
+	})
+
+	t.Run("GET /league breaks ties with the tie-breaks asked for", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetLeagueQueryRequest("tiebreak=fewest-games"))
+
+		var stats []poker.PlayerStats
+		decodeJSON(t, response, &stats)
+
+		assertRanks(t, stats, []string{"1 Chris", "2 Cleo", "3 Ruth"})
+	})
+
+	t.Run("GET /league returns 400 for an unknown tie-break", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetLeagueQueryRequest("tiebreak=height"))
+
+		assertStatus(t, response, http.StatusBadRequest)
+	})
+}
+
+func assertRanks(t testing.TB, stats []poker.PlayerStats, want []string) {
+	t.Helper()
+
+	var got []string
+	for _, player := range stats {
+		got = append(got, fmt.Sprintf("%d %s", player.Rank, player.Name))
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got ranks %v want %v", got, want)
+	}
+}
@@ -0,0 +1,200 @@
+package poker
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// InitialRating is the rating of a player before their first rated game.
+const InitialRating = 1500.0
+
+// RatingAlgorithm works out the ratings of a game's players after it from
+// their ratings before it. Both slices are in the order of players.
+type RatingAlgorithm interface {
+	Rate(players []string, ratings []float64, winner string) []float64
+}
+
+// Elo is the Elo rating system, generalised to games of more than two
+// players by scoring every pair of players in the game: the winner beats
+// everyone and the other players draw with each other. A player's K is shared
+// between their opponents, so with two players it is the classic system.
+type Elo struct {
+	K float64
+}
+
+var DefaultRatingAlgorithm RatingAlgorithm = Elo{K: 32}
+
+func (e Elo) Rate(players []string, ratings []float64, winner string) []float64 {
+	rated := make([]float64, len(players))
+
+	for i := range players {
+		var score float64
+
+		for j := range players {
+			if i == j {
+				continue
+			}
+
+			actual := 0.5
+			switch playerKey(winner) {
+			case playerKey(players[i]):
+				actual = 1
+			case playerKey(players[j]):
+				actual = 0
+			}
+
+			expected := 1 / (1 + math.Pow(10, (ratings[j]-ratings[i])/400))
+			score += actual - expected
+		}
+
+		rated[i] = ratings[i] + e.K*score/float64(len(players)-1)
+	}
+
+	return rated
+}
+
+// RatingStore is implemented by game stores that rate the players of every
+// game recorded with its players' names.
+type RatingStore interface {
+	GetRatings() ([]Rating, error)
+	GetRatingHistory(player string) ([]RatingChange, error)
+	RecomputeRatings() error
+}
+
+// Rating is a player's current rating and the number of rated games it comes
+// from.
+type Rating struct {
+	Name   string
+	Rating float64
+	Games  int
+}
+
+// PlayerRating is how a game changed one of its player's rating.
+type PlayerRating struct {
+	Player string
+	Before float64
+	After  float64
+}
+
+// RatingChange is one entry in a player's rating history.
+type RatingChange struct {
+	GameID int
+	At     time.Time
+	Before float64
+	After  float64
+}
+
+// rateGame returns the rating changes of game given the current ratings, or
+// nil when fewer than two of its players are known.
+func rateGame(algorithm RatingAlgorithm, current map[string]Rating, game GameRecord) []PlayerRating {
+	if len(game.Players) < 2 {
+		return nil
+	}
+
+	before := make([]float64, len(game.Players))
+
+	for i, player := range game.Players {
+		before[i] = InitialRating
+		if rating, ok := current[playerKey(player)]; ok {
+			before[i] = rating.Rating
+		}
+	}
+
+	after := algorithm.Rate(game.Players, before, game.Winner)
+	changes := make([]PlayerRating, len(game.Players))
+
+	for i, player := range game.Players {
+		changes[i] = PlayerRating{Player: player, Before: before[i], After: after[i]}
+	}
+
+	return changes
+}
+
+// currentRatings returns every rated player's latest rating, by player key.
+func currentRatings(games []GameRecord) map[string]Rating {
+	ratings := map[string]Rating{}
+
+	for _, game := range games {
+		applyRatings(ratings, game.Ratings)
+	}
+
+	return ratings
+}
+
+func applyRatings(ratings map[string]Rating, changes []PlayerRating) {
+	for _, change := range changes {
+		rating := ratings[playerKey(change.Player)]
+		ratings[playerKey(change.Player)] = Rating{Name: change.Player, Rating: change.After, Games: rating.Games + 1}
+	}
+}
+
+func (f *FileSystemGameStore) GetRatings() ([]Rating, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	ratings := []Rating{}
+	for _, rating := range currentRatings(f.games) {
+		ratings = append(ratings, rating)
+	}
+
+	sort.Slice(ratings, func(i, j int) bool {
+		if ratings[i].Rating != ratings[j].Rating {
+			return ratings[i].Rating > ratings[j].Rating
+		}
+		return ratings[i].Name < ratings[j].Name
+	})
+
+	return ratings, nil
+}
+
+// GetRatingHistory returns how each rated game changed the player's rating,
+// oldest first.
+func (f *FileSystemGameStore) GetRatingHistory(player string) ([]RatingChange, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	key := playerKey(player)
+	history := []RatingChange{}
+
+	for _, game := range f.games {
+		for _, change := range game.Ratings {
+			if playerKey(change.Player) == key {
+				history = append(history, RatingChange{GameID: game.ID, At: game.FinishedAt, Before: change.Before, After: change.After})
+			}
+		}
+	}
+
+	return history, nil
+}
+
+// RecomputeRatings rates every game in the history again, oldest first, with
+// the store's current rating algorithm.
+func (f *FileSystemGameStore) RecomputeRatings() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	unlock, err := f.lockFile()
+
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	games := make([]GameRecord, len(f.games))
+	ratings := map[string]Rating{}
+
+	for i, game := range f.games {
+		game.Ratings = rateGame(f.Rater, ratings, game)
+		applyRatings(ratings, game.Ratings)
+		games[i] = game
+	}
+
+	if err := f.save(games, f.ledger, f.open); err != nil {
+		return fmt.Errorf("problem saving ratings, %v", err)
+	}
+
+	f.games = games
+	return nil
+}
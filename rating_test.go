@@ -0,0 +1,206 @@
+package poker_test
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func TestElo(t *testing.T) {
+	elo := poker.Elo{K: 32}
+
+	t.Run("is the classic system for two players", func(t *testing.T) {
+		got := elo.Rate([]string{"Chris", "Cleo"}, []float64{1500, 1500}, "Chris")
+
+		assertRatings(t, got, []float64{1516, 1484})
+	})
+
+	t.Run("moves an upset further than an expected result", func(t *testing.T) {
+		expected := elo.Rate([]string{"Chris", "Cleo"}, []float64{1700, 1500}, "Chris")
+		upset := elo.Rate([]string{"Chris", "Cleo"}, []float64{1700, 1500}, "Cleo")
+
+		if gain, loss := expected[0]-1700, 1700-upset[0]; gain >= loss {
+			t.Errorf("got a gain of %.2f for the expected win and a loss of %.2f for the upset", gain, loss)
+		}
+	})
+
+	t.Run("shares points between more players", func(t *testing.T) {
+		got := elo.Rate([]string{"Chris", "Cleo", "Ruth"}, []float64{1500, 1500, 1500}, "ruth")
+
+		assertRatings(t, got, []float64{1492, 1492, 1516})
+	})
+}
+
+type fixedRating float64
+
+func (r fixedRating) Rate(players []string, ratings []float64, winner string) []float64 {
+	rated := make([]float64, len(players))
+	for i := range rated {
+		rated[i] = float64(r)
+	}
+	return rated
+}
+
+func TestFileSystemGameStoreRatings(t *testing.T) {
+	started := time.Date(2026, 10, 13, 19, 0, 0, 0, time.UTC)
+	game := func(winner string, players ...string) poker.GameRecord {
+		return poker.GameRecord{StartedAt: started, FinishedAt: started.Add(time.Hour), NumberOfPlayers: len(players), Players: players, Winner: winner}
+	}
+
+	t.Run("rates the players of games recorded with their names", func(t *testing.T) {
+		store := mustMakeGameStore(t)
+
+		mustRecordGame(t, store, game("Chris", "Chris", "Cleo"))
+		mustRecordGame(t, store, game("Chris", "Chris", "Cleo"))
+		mustRecordGame(t, store, poker.GameRecord{NumberOfPlayers: 5, Winner: "Ruth"})
+
+		ratings, err := store.GetRatings()
+		poker.AssertNoError(t, err)
+
+		if len(ratings) != 2 || ratings[0].Name != "Chris" || ratings[0].Games != 2 || ratings[1].Name != "Cleo" {
+			t.Fatalf("got ratings %+v want Chris ahead of Cleo after two games", ratings)
+		}
+
+		history, err := store.GetRatingHistory("chris")
+		poker.AssertNoError(t, err)
+
+		if len(history) != 2 || history[0].GameID != 1 || history[0].Before != poker.InitialRating || history[1].Before != history[0].After {
+			t.Errorf("got rating history %+v", history)
+		}
+	})
+
+	t.Run("recomputes every rating with the current algorithm", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+
+		store, closeStore, err := poker.FileSystemGameStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		mustRecordGame(t, store, game("Chris", "Chris", "Cleo"))
+
+		store.Rater = fixedRating(1000)
+		poker.AssertNoError(t, store.RecomputeRatings())
+		closeStore()
+
+		reopened, closeReopened, err := poker.FileSystemGameStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeReopened()
+
+		ratings, err := reopened.GetRatings()
+		poker.AssertNoError(t, err)
+
+		for _, rating := range ratings {
+			if rating.Rating != 1000 {
+				t.Errorf("got rating %+v want 1000", rating)
+			}
+		}
+	})
+
+	t.Run("loads a version 1 game history", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `{"version":1,"games":[{"ID":1,"Players":["Chris","Cleo"],"Winner":"Cleo"}]}`)
+		defer cleanDatabase()
+
+		store, err := poker.NewFileSystemGameStore(database)
+		poker.AssertNoError(t, err)
+		poker.AssertNoError(t, store.RecomputeRatings())
+
+		if ratings, _ := store.GetRatings(); len(ratings) != 2 || ratings[0].Name != "Cleo" {
+			t.Errorf("got ratings %+v want Cleo first", ratings)
+		}
+	})
+}
+
+func TestRatingsOverHTTP(t *testing.T) {
+	games := mustMakeGameStore(t)
+	mustRecordGame(t, games, poker.GameRecord{Players: []string{"Chris", "Cleo"}, Winner: "Cleo"})
+
+	store := &poker.StubPlayerStore{League: []poker.Player{{"Chris", 3}, {"Cleo", 1}}}
+	server, _ := poker.NewPlayerServer(store, games, dummyGame)
+
+	t.Run("GET /league?sort=rating orders the league by rating", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/league?sort=rating", nil)
+		server.ServeHTTP(response, request)
+
+		var stats []poker.PlayerStats
+		if err := json.NewDecoder(response.Body).Decode(&stats); err != nil {
+			t.Fatalf("could not parse league, %v", err)
+		}
+
+		if len(stats) != 2 || stats[0].Name != "Cleo" || stats[0].Rating <= stats[1].Rating {
+			t.Errorf("got league %+v want Cleo first", stats)
+		}
+	})
+
+	t.Run("GET /league returns 400 for an unknown order", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/league?sort=height", nil)
+		server.ServeHTTP(response, request)
+
+		assertStatus(t, response, http.StatusBadRequest)
+	})
+
+	t.Run("GET /players/{name}/ratings returns the rating history", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetRatingHistoryRequest("Cleo"))
+
+		assertStatus(t, response, http.StatusOK)
+
+		var history []poker.RatingChange
+		if err := json.NewDecoder(response.Body).Decode(&history); err != nil {
+			t.Fatalf("could not parse rating history, %v", err)
+		}
+
+		if len(history) != 1 || history[0].After != 1516 {
+			t.Errorf("got rating history %+v", history)
+		}
+	})
+
+	t.Run("returns 501 when the game store does not rate players", func(t *testing.T) {
+		server, _ := poker.NewPlayerServer(store, &poker.StubGameStore{}, dummyGame)
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetRatingHistoryRequest("Cleo"))
+
+		assertStatus(t, response, http.StatusNotImplemented)
+	})
+}
+
+func mustMakeGameStore(t *testing.T) *poker.FileSystemGameStore {
+	t.Helper()
+
+	database, cleanDatabase := createTempFile(t, "")
+	t.Cleanup(cleanDatabase)
+
+	store, err := poker.NewFileSystemGameStore(database)
+	poker.AssertNoError(t, err)
+
+	return store
+}
+
+func mustRecordGame(t *testing.T, store poker.GameStore, game poker.GameRecord) poker.GameRecord {
+	t.Helper()
+
+	recorded, err := store.RecordGame(game)
+	poker.AssertNoError(t, err)
+
+	return recorded
+}
+
+func assertRatings(t testing.TB, got, want []float64) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got ratings %v want %v", got, want)
+	}
+
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 0.01 {
+			t.Errorf("got ratings %v want %v", got, want)
+			return
+		}
+	}
+}
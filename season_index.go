@@ -0,0 +1,85 @@
+package poker
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// seasonFile is the form a season is saved in, with its league in standings
+// order.
+type seasonFile struct {
+	Name      string     `json:"name"`
+	StartedAt time.Time  `json:"startedAt"`
+	ClosedAt  *time.Time `json:"closedAt,omitempty"`
+	Players   League     `json:"players"`
+}
+
+func (s seasonRecord) MarshalJSON() ([]byte, error) {
+	return json.Marshal(seasonFile{s.Name, s.StartedAt, s.ClosedAt, s.standings()})
+}
+
+func (s *seasonRecord) UnmarshalJSON(data []byte) error {
+	var file seasonFile
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+
+	*s = seasonRecord{Name: file.Name, StartedAt: file.StartedAt, ClosedAt: file.ClosedAt, league: newLeagueIndex(file.Players)}
+	return nil
+}
+
+func (s *seasonRecord) has(player string) bool {
+	return s.league.find(player) != nil
+}
+
+func (s *seasonRecord) wins(player string) int {
+	if p := s.league.find(player); p != nil {
+		return p.Wins
+	}
+	return 0
+}
+
+// setWins sets a player's wins in the season, a player with no wins is
+// removed from it.
+func (s *seasonRecord) setWins(player string, wins int) {
+	s.league.add(player, wins-s.wins(player))
+}
+
+// setAllWins sets the wins of each of players at once, indexing the season
+// again when they've all been set rather than moving each of them in turn.
+func (s *seasonRecord) setAllWins(players League) {
+	if len(players) == 0 {
+		return
+	}
+
+	league := s.league.league()
+	places := make(map[string]int, len(league))
+
+	for i, player := range league {
+		places[playerKey(player.Name)] = i
+	}
+
+	for _, player := range players {
+		if i, ok := places[playerKey(player.Name)]; ok {
+			league[i].Wins = player.Wins
+		} else {
+			league = append(league, player)
+		}
+	}
+
+	s.league = newLeagueIndex(league)
+}
+
+// rename moves a player's wins in the season to their new name.
+func (s *seasonRecord) rename(from, to string) {
+	if wins := s.wins(from); wins != 0 {
+		s.league.add(from, -wins)
+		s.league.add(to, wins)
+	}
+}
+
+// standings returns a copy of the season's players in standings order.
+func (s *seasonRecord) standings() League {
+	return s.league.league()
+}
@@ -0,0 +1,110 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSeasonNotFound = errors.New("season not found")
+	ErrSeasonExists   = errors.New("season already exists")
+)
+
+// SeasonStore is implemented by player stores that keep a league per season.
+// The PlayerStore methods of such a store act on the active season.
+type SeasonStore interface {
+	GetSeasons() ([]Season, error)
+	GetSeasonLeague(season string) (League, error)
+	GetSeasonPlayerScore(season, name string) (int, error)
+	StartSeason(name string) error
+}
+
+// Season describes one season of the league. ClosedAt is nil for the active
+// season.
+type Season struct {
+	Name      string
+	StartedAt time.Time
+	ClosedAt  *time.Time
+}
+
+func (s Season) Active() bool {
+	return s.ClosedAt == nil
+}
+
+// window is the time the season ran for, open ended for the active season.
+func (s Season) window() Window {
+	window := Window{From: s.StartedAt}
+
+	if s.ClosedAt != nil {
+		window.To = *s.ClosedAt
+	}
+
+	return window
+}
+
+func (f *FileSystemPlayerStore) GetSeasons() ([]Season, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	seasons := make([]Season, len(f.db.Seasons))
+	for i, season := range f.db.Seasons {
+		seasons[i] = Season{Name: season.Name, StartedAt: season.StartedAt, ClosedAt: season.ClosedAt}
+	}
+	return seasons, nil
+}
+
+func (f *FileSystemPlayerStore) GetSeasonLeague(name string) (League, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	season := f.db.findSeason(name)
+
+	if season == nil {
+		return nil, fmt.Errorf("%w: %s", ErrSeasonNotFound, name)
+	}
+
+	return season.standings(), nil
+}
+
+func (f *FileSystemPlayerStore) GetSeasonPlayerScore(name, player string) (int, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	season := f.db.findSeason(name)
+
+	if season == nil {
+		return 0, fmt.Errorf("%w: %s", ErrSeasonNotFound, name)
+	}
+
+	return season.wins(f.db.resolve(player)), nil
+}
+
+// StartSeason closes the active season, keeping its standings readable, and
+// starts a new empty season that wins are recorded against from now on.
+func (f *FileSystemPlayerStore) StartSeason(name string) error {
+	if err := validSeasonName(name); err != nil {
+		return err
+	}
+
+	return f.update(func(db *playerDB) error {
+		if db.findSeason(name) != nil {
+			return fmt.Errorf("%w: %s", ErrSeasonExists, name)
+		}
+
+		now := time.Now().UTC()
+		db.activeSeason().ClosedAt = &now
+		db.Seasons = append(db.Seasons, seasonRecord{Name: name, StartedAt: now, league: newLeagueIndex(nil)})
+
+		db.log(auditRecord{Action: startSeasonAction, To: name})
+		return nil
+	})
+}
+
+func validSeasonName(name string) error {
+	if strings.TrimSpace(name) == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("invalid season name %q", name)
+	}
+	return nil
+}
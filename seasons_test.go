@@ -0,0 +1,183 @@
+package poker_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func TestFileSystemPlayerStoreSeasons(t *testing.T) {
+	t.Run("records wins against the active season", func(t *testing.T) {
+		store := mustMakeSeasonedStore(t)
+
+		poker.AssertScore(t, store, "Chris", 0)
+		poker.MustRecordWin(t, store, "Chris")
+		poker.AssertScore(t, store, "Chris", 1)
+
+		assertSeasonScore(t, store, "2026-Q4", "Chris", 1)
+		assertSeasonScore(t, store, "default", "Chris", 33)
+	})
+
+	t.Run("keeps closed seasons readable", func(t *testing.T) {
+		store := mustMakeSeasonedStore(t)
+
+		league, err := store.GetSeasonLeague("default")
+		poker.AssertNoError(t, err)
+
+		want := []poker.Player{
+			{"Chris", 33},
+			{"Cleo", 10},
+		}
+		poker.AssertLeague(t, league, want)
+	})
+
+	t.Run("lists seasons with only the last one active", func(t *testing.T) {
+		store := mustMakeSeasonedStore(t)
+
+		seasons, err := store.GetSeasons()
+		poker.AssertNoError(t, err)
+
+		if len(seasons) != 2 {
+			t.Fatalf("got %d seasons want %d", len(seasons), 2)
+		}
+
+		if seasons[0].Name != "default" || seasons[0].Active() {
+			t.Errorf("got first season %+v want closed default season", seasons[0])
+		}
+
+		if seasons[1].Name != "2026-Q4" || !seasons[1].Active() {
+			t.Errorf("got second season %+v want active 2026-Q4 season", seasons[1])
+		}
+	})
+
+	t.Run("refuses to start a season that already exists", func(t *testing.T) {
+		store := mustMakeSeasonedStore(t)
+
+		err := store.StartSeason("default")
+
+		if !errors.Is(err, poker.ErrSeasonExists) {
+			t.Errorf("got error %v want %v", err, poker.ErrSeasonExists)
+		}
+	})
+
+	t.Run("keeps seasons across reopening the file", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `[{"Name": "Chris", "Wins": 33}]`)
+		defer cleanDatabase()
+
+		store, closeStore, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		poker.AssertNoError(t, store.StartSeason("2026-Q4"))
+		poker.MustRecordWin(t, store, "Chris")
+		closeStore()
+
+		reopened, closeReopened, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeReopened()
+
+		poker.AssertScore(t, reopened, "Chris", 1)
+		assertSeasonScore(t, reopened, "default", "Chris", 33)
+	})
+}
+
+func TestSeasonsOverHTTP(t *testing.T) {
+	store := mustMakeSeasonedStore(t)
+	poker.MustRecordWin(t, store, "Cleo")
+	server := mustMakePlayerServer(t, store, dummyGame)
+
+	t.Run("GET /leagues/{season} returns that season's league", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetSeasonLeagueRequest("default"))
+
+		assertStatus(t, response, http.StatusOK)
+		want := []poker.Player{
+			{"Chris", 33},
+			{"Cleo", 10},
+		}
+		poker.AssertLeague(t, poker.GetLeagueFromResponse(t, response.Body), want)
+	})
+
+	t.Run("GET /leagues/{season} ranks the league as /league does", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetSeasonLeagueRequest("default"))
+
+		var stats []poker.PlayerStats
+		decodeJSON(t, response, &stats)
+
+		if len(stats) != 2 || stats[0].Rank != 1 || stats[1].Rank != 2 {
+			t.Errorf("got league %+v want Chris and Cleo ranked 1 and 2", stats)
+		}
+	})
+
+	t.Run("GET /league returns the active season", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetLeagueRequest())
+
+		want := []poker.Player{
+			{"Cleo", 1},
+		}
+		poker.AssertLeague(t, poker.GetLeagueFromResponse(t, response.Body), want)
+	})
+
+	t.Run("GET /leagues/{season}/players/{name} returns the season score", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetSeasonScoreRequest("default", "Cleo"))
+
+		assertStatus(t, response, http.StatusOK)
+		poker.AssertResponseBody(t, response.Body.String(), "10")
+	})
+
+	t.Run("GET /leagues/{season} returns 404 for an unknown season", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetSeasonLeagueRequest("1999"))
+
+		assertStatus(t, response, http.StatusNotFound)
+	})
+
+	t.Run("GET /leagues lists the seasons", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetSeasonsRequest())
+
+		assertStatus(t, response, http.StatusOK)
+		poker.AssertContentType(t, response, "application/json")
+	})
+
+	t.Run("returns 501 when the store has no seasons", func(t *testing.T) {
+		server := mustMakePlayerServer(t, &poker.StubPlayerStore{}, dummyGame)
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetSeasonLeagueRequest("default"))
+
+		assertStatus(t, response, http.StatusNotImplemented)
+	})
+}
+
+func mustMakeSeasonedStore(t *testing.T) *poker.FileSystemPlayerStore {
+	t.Helper()
+
+	database, cleanDatabase := createTempFile(t, `[
+      {"Name": "Cleo", "Wins": 10},
+      {"Name": "Chris", "Wins": 33}]`)
+	t.Cleanup(cleanDatabase)
+
+	store, err := poker.NewFileSystemPlayerStore(database)
+	poker.AssertNoError(t, err)
+	poker.AssertNoError(t, store.StartSeason("2026-Q4"))
+
+	return store
+}
+
+func assertSeasonScore(t testing.TB, store poker.SeasonStore, season, name string, want int) {
+	t.Helper()
+
+	got, err := store.GetSeasonPlayerScore(season, name)
+
+	if err != nil {
+		t.Fatalf("didn't expect an error but got: %v", err)
+	}
+
+	if got != want {
+		t.Errorf("got %d wins for %s in %s, want %d", got, name, season, want)
+	}
+}
@@ -2,18 +2,22 @@ package poker
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
 type PlayerStore interface {
-	GetPlayerScore(name string) int
-	RecordWin(name string)
-	GetLeague() League
+	GetPlayerScore(name string) (int, error)
+	RecordWin(name string) error
+	GetLeague() (League, error)
 }
 
 type Player struct {
@@ -23,15 +27,33 @@ type Player struct {
 
 type PlayerServer struct {
 	store PlayerStore
+	games GameStore
 	http.Handler
 	template *template.Template
 	game     Game
+
+	// TieBreaks order players level on wins, or on rating, in the league
+	// when a request doesn't ask for its own with tiebreak.
+	TieBreaks []Ranking
+
+	// AdminToken is the bearer token the /admin endpoints require. They're
+	// turned off while it's empty.
+	AdminToken string
 }
 
 const jsonContentType = "application/json"
 const htmlTemplatePath = "game.html"
 
-func NewPlayerServer(store PlayerStore, game Game) (*PlayerServer, error) {
+var (
+	errSeasonsNotSupported = errors.New("this player store does not support seasons")
+	errAliasesNotSupported = errors.New("this player store does not support aliases")
+	errAdminNotSupported   = errors.New("this player store does not support correcting players")
+	errAuditNotSupported   = errors.New("this player store does not keep an audit log")
+	errRatingsNotSupported = errors.New("this game store does not rate players")
+	errLedgerNotSupported  = errors.New("this game store does not keep a ledger")
+)
+
+func NewPlayerServer(store PlayerStore, games GameStore, game Game) (*PlayerServer, error) {
 	p := new(PlayerServer)
 
 	tmpl, err := template.ParseFiles(htmlTemplatePath)
@@ -44,33 +66,737 @@ func NewPlayerServer(store PlayerStore, game Game) (*PlayerServer, error) {
 
 	p.template = tmpl
 	p.store = store
+	p.games = games
 
 	router := http.NewServeMux()
 	router.Handle("/league", http.HandlerFunc(p.leagueHander))
+	router.Handle("/league.csv", http.HandlerFunc(p.leagueCSVHandler))
 	router.Handle("/players/", http.HandlerFunc(p.playersHandler))
 	router.Handle("/game", http.HandlerFunc(p.playGame))
 	router.Handle("/ws", http.HandlerFunc(p.websocket))
+	router.Handle("/leagues", http.HandlerFunc(p.seasonsHandler))
+	router.Handle("/leagues/", http.HandlerFunc(p.seasonHandler))
+	router.Handle("/audit", http.HandlerFunc(p.auditHandler))
+	router.Handle("/audit/", p.requireAdmin(p.undoHandler))
+	router.Handle("/ledger", http.HandlerFunc(p.ledgerHandler))
+	router.Handle("/balances", http.HandlerFunc(p.balancesHandler))
+	router.Handle("/settle-up", http.HandlerFunc(p.settleUpHandler))
+	router.Handle("/games", http.HandlerFunc(p.gamesHandler))
+	router.Handle("/games/", http.HandlerFunc(p.gameHandler))
+	router.Handle("/games.csv", http.HandlerFunc(p.gamesCSVHandler))
+	router.Handle("/admin/snapshots", p.requireAdmin(p.snapshotsHandler))
+	router.Handle("/admin/snapshots/", p.requireAdmin(p.restoreHandler))
+	router.Handle("/admin/import", p.requireAdmin(p.importHandler))
+	router.Handle("/admin/metrics", p.requireAdmin(p.metricsHandler))
 
 	p.Handler = router
 
 	return p, nil
 }
 
+// leagueHander returns the league with each player's stats and rank, ranked
+// by wins or, with sort=rating, by rating, then by the tie-breaks listed in
+// tiebreak. Asking for a window counts only the wins and games in it.
 func (p *PlayerServer) leagueHander(w http.ResponseWriter, r *http.Request) {
+	rankings, err := p.leagueRankings(r)
+
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	window, windowed, err := leagueWindow(r)
+
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var league League
+
+	if windowed {
+		windows, ok := StoreAs[WindowStore](p.store)
+
+		if !ok {
+			writeJSONError(w, http.StatusNotImplemented, fmt.Errorf("the player store does not support league windows"))
+			return
+		}
+
+		league, err = windows.GetWindowLeague(window)
+	} else {
+		league, err = p.store.GetLeague()
+	}
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	games, err := p.statsGames()
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if windowed {
+		games = gamesIn(window, games)
+	}
+
+	stats := CalculateStats(league, games)
+	RankStats(stats, rankings...)
+
 	w.Header().Set("content-type", jsonContentType)
-	json.NewEncoder(w).Encode(p.store.GetLeague())
+	json.NewEncoder(w).Encode(stats)
+}
+
+// statsGames returns the game history to work out stats from, with the
+// players named as the player store resolves them when it has aliases.
+func (p *PlayerServer) statsGames() ([]GameRecord, error) {
+	games, err := p.games.GetGames()
+
+	if err != nil {
+		return nil, err
+	}
+
+	aliases, ok := StoreAs[AliasStore](p.store)
+
+	if !ok {
+		return games, nil
+	}
+
+	names := map[string]string{}
+
+	games = resolvePlayers(games, func(name string) string {
+		if resolved, ok := names[playerKey(name)]; ok {
+			return resolved
+		}
+
+		resolved, resolveErr := aliases.ResolvePlayer(name)
+
+		if resolveErr != nil {
+			err = resolveErr
+			return name
+		}
+
+		names[playerKey(name)] = resolved
+		return resolved
+	})
+
+	return games, err
+}
+
+// leagueRankings returns the rankings to order a league by, the one asked for
+// with sort followed by the tie-breaks.
+func (p *PlayerServer) leagueRankings(r *http.Request) ([]Ranking, error) {
+	order := r.URL.Query().Get("sort")
+	rankings := []Ranking{ByWins}
+
+	switch order {
+	case "", "wins":
+	case "rating":
+		rankings = []Ranking{ByRating}
+	default:
+		return nil, fmt.Errorf("can't sort the league by %q", order)
+	}
+
+	tieBreaks := p.TieBreaks
+
+	if names := r.URL.Query().Get("tiebreak"); names != "" {
+		var err error
+
+		if tieBreaks, err = ParseTieBreaks(names); err != nil {
+			return nil, err
+		}
+	}
+
+	return append(rankings, tieBreaks...), nil
+}
+
+// leagueWindow returns the window asked for with window=week, month or
+// rolling-Nd, or with from and to, and whether one was asked for at all.
+func leagueWindow(r *http.Request) (Window, bool, error) {
+	query := r.URL.Query()
+	name, from, to := query.Get("window"), query.Get("from"), query.Get("to")
+
+	switch {
+	case name != "" && (from != "" || to != ""):
+		return Window{}, true, fmt.Errorf("%w: ask for a window or from and to, not both", ErrInvalidWindow)
+	case name != "":
+		window, err := ParseWindow(name, time.Now())
+		return window, true, err
+	case from != "" || to != "":
+		window, err := NewWindow(from, to)
+		return window, true, err
+	default:
+		return Window{}, false, nil
+	}
 }
 
 func (p *PlayerServer) playersHandler(w http.ResponseWriter, r *http.Request) {
-	player := r.URL.Path[len("/players/"):]
+	player, rest, found := strings.Cut(r.URL.Path[len("/players/"):], "/")
+
+	if found {
+		p.playerAdminHandler(w, r, player, rest)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodPost:
-		p.processWin(w, player)
+		p.processWin(w, r, player)
 	case http.MethodGet:
-		p.showScore(w, player)
+		p.showScore(w, r, player)
+	case http.MethodDelete:
+		p.correctPlayer(w, r, func(admin PlayerAdminStore, reason string) error {
+			return admin.DeletePlayer(player, reason)
+		})
+	}
+}
+
+func (p *PlayerServer) playerAdminHandler(w http.ResponseWriter, r *http.Request, player, rest string) {
+	action, arg, _ := strings.Cut(rest, "/")
+
+	switch action {
+	case "aliases", "merge-into":
+		p.aliasHandler(w, r, player, action, arg)
+	case "rename", "score", "changes":
+		p.correctionHandler(w, r, player, action, arg)
+	case "ratings":
+		p.showRatingHistory(w, r, player)
+	default:
+		http.NotFound(w, r)
 	}
 }
 
+func (p *PlayerServer) aliasHandler(w http.ResponseWriter, r *http.Request, player, action, name string) {
+	aliases, ok := StoreAs[AliasStore](p.storeFor(r))
+
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, errAliasesNotSupported)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && action == "aliases" && name == "":
+		p.showAliases(w, aliases, player)
+	case r.Method == http.MethodPost && action == "aliases" && name != "":
+		p.requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+			p.processAliasChange(w, aliases.AddAlias(player, name))
+		})(w, r)
+	case r.Method == http.MethodPost && action == "merge-into" && name != "":
+		p.requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+			p.processAliasChange(w, aliases.MergePlayers(player, name))
+		})(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// correctionHandler serves the hand corrections to a player. The reason for a
+// change is given in the reason query parameter.
+func (p *PlayerServer) correctionHandler(w http.ResponseWriter, r *http.Request, player, action, arg string) {
+	switch {
+	case r.Method == http.MethodGet && action == "changes" && arg == "":
+		p.showPlayerChanges(w, player)
+	case r.Method == http.MethodPost && action == "rename" && arg != "":
+		p.correctPlayer(w, r, func(admin PlayerAdminStore, reason string) error {
+			return admin.RenamePlayer(player, arg, reason)
+		})
+	case (r.Method == http.MethodPut || r.Method == http.MethodPost) && action == "score" && arg != "":
+		p.correctPlayer(w, r, func(admin PlayerAdminStore, reason string) error {
+			wins, err := strconv.Atoi(arg)
+
+			if err != nil {
+				return fmt.Errorf("%w: %q is not a number", ErrInvalidScore, arg)
+			}
+
+			if r.Method == http.MethodPut {
+				return admin.SetPlayerScore(player, wins, reason)
+			}
+			return admin.AdjustPlayerScore(player, wins, reason)
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// correctPlayer makes a hand correction, which needs the admin token.
+func (p *PlayerServer) correctPlayer(w http.ResponseWriter, r *http.Request, correct func(PlayerAdminStore, string) error) {
+	p.requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+		admin, ok := StoreAs[PlayerAdminStore](p.storeFor(r))
+
+		if !ok {
+			writeJSONError(w, http.StatusNotImplemented, errAdminNotSupported)
+			return
+		}
+
+		err := correct(admin, r.URL.Query().Get("reason"))
+
+		switch {
+		case errors.Is(err, ErrPlayerNotFound):
+			writeJSONError(w, http.StatusNotFound, err)
+		case errors.Is(err, ErrPlayerExists):
+			writeJSONError(w, http.StatusConflict, err)
+		case errors.Is(err, ErrReasonRequired), errors.Is(err, ErrInvalidScore):
+			writeJSONError(w, http.StatusBadRequest, err)
+		case err != nil:
+			writeJSONError(w, http.StatusInternalServerError, err)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	})(w, r)
+}
+
+func (p *PlayerServer) showRatingHistory(w http.ResponseWriter, r *http.Request, player string) {
+	ratings, ok := p.games.(RatingStore)
+
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, errRatingsNotSupported)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+
+	history, err := ratings.GetRatingHistory(player)
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("content-type", jsonContentType)
+	json.NewEncoder(w).Encode(history)
+}
+
+func (p *PlayerServer) showPlayerChanges(w http.ResponseWriter, player string) {
+	audit, ok := StoreAs[AuditStore](p.store)
+
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, errAuditNotSupported)
+		return
+	}
+
+	changes, err := audit.GetPlayerChanges(player)
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("content-type", jsonContentType)
+	json.NewEncoder(w).Encode(changes)
+}
+
+func (p *PlayerServer) showAliases(w http.ResponseWriter, aliases AliasStore, player string) {
+	list, err := aliases.GetAliases(player)
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("content-type", jsonContentType)
+	json.NewEncoder(w).Encode(list)
+}
+
+func (p *PlayerServer) processAliasChange(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrPlayerNotFound):
+		writeJSONError(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrAliasConflict):
+		writeJSONError(w, http.StatusConflict, err)
+	case err != nil:
+		writeJSONError(w, http.StatusInternalServerError, err)
+	default:
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func (p *PlayerServer) seasonsHandler(w http.ResponseWriter, r *http.Request) {
+	seasons, ok := StoreAs[SeasonStore](p.store)
+
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, errSeasonsNotSupported)
+		return
+	}
+
+	list, err := seasons.GetSeasons()
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("content-type", jsonContentType)
+	json.NewEncoder(w).Encode(list)
+}
+
+func (p *PlayerServer) seasonHandler(w http.ResponseWriter, r *http.Request) {
+	seasons, ok := StoreAs[SeasonStore](p.store)
+
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, errSeasonsNotSupported)
+		return
+	}
+
+	parts := strings.Split(r.URL.Path[len("/leagues/"):], "/")
+
+	switch {
+	case len(parts) == 1:
+		p.showSeasonLeague(w, r, seasons, parts[0])
+	case len(parts) == 3 && parts[1] == "players":
+		p.showSeasonScore(w, seasons, parts[0], parts[2])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// showSeasonLeague returns a season's league ranked as /league is, with the
+// stats from the games finished while the season was running.
+func (p *PlayerServer) showSeasonLeague(w http.ResponseWriter, r *http.Request, seasons SeasonStore, season string) {
+	rankings, err := p.leagueRankings(r)
+
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	league, err := seasons.GetSeasonLeague(season)
+
+	if errors.Is(err, ErrSeasonNotFound) {
+		writeJSONError(w, http.StatusNotFound, err)
+		return
+	}
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	list, err := seasons.GetSeasons()
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	games, err := p.statsGames()
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	for _, s := range list {
+		if s.Name == season {
+			games = gamesIn(s.window(), games)
+		}
+	}
+
+	stats := CalculateStats(league, games)
+	RankStats(stats, rankings...)
+
+	w.Header().Set("content-type", jsonContentType)
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (p *PlayerServer) showSeasonScore(w http.ResponseWriter, seasons SeasonStore, season, player string) {
+	score, err := seasons.GetSeasonPlayerScore(season, player)
+
+	if errors.Is(err, ErrSeasonNotFound) {
+		writeJSONError(w, http.StatusNotFound, err)
+		return
+	}
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if score == 0 {
+		w.WriteHeader(http.StatusNotFound)
+	}
+
+	fmt.Fprint(w, score)
+}
+
+// storeFor returns the store to make the changes r asks for through, so the
+// audit log records who asked for them.
+func (p *PlayerServer) storeFor(r *http.Request) PlayerStore {
+	return StoreFrom(p.store, HTTPSource(r.RemoteAddr))
+}
+
+// auditHandler lists the audit log, oldest first. The player query parameter
+// limits it to one player's changes and limit to the latest entries.
+func (p *PlayerServer) auditHandler(w http.ResponseWriter, r *http.Request) {
+	audit, ok := StoreAs[AuditStore](p.store)
+
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, errAuditNotSupported)
+		return
+	}
+
+	query := r.URL.Query()
+	var entries []AuditEntry
+	var err error
+
+	if player := query.Get("player"); player != "" {
+		entries, err = audit.GetPlayerChanges(player)
+	} else {
+		entries, err = audit.GetAuditLog()
+	}
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+
+		if err != nil || n < 0 {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("bad limit %q", limit))
+			return
+		}
+
+		if n < len(entries) {
+			entries = entries[len(entries)-n:]
+		}
+	}
+
+	w.Header().Set("content-type", jsonContentType)
+	json.NewEncoder(w).Encode(entries)
+}
+
+// undoHandler reverts the audit log entry POST /audit/{id}/undo names.
+func (p *PlayerServer) undoHandler(w http.ResponseWriter, r *http.Request) {
+	audit, ok := StoreAs[AuditStore](p.storeFor(r))
+
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, errAuditNotSupported)
+		return
+	}
+
+	entry, action, _ := strings.Cut(r.URL.Path[len("/audit/"):], "/")
+
+	if r.Method != http.MethodPost || action != "undo" {
+		http.NotFound(w, r)
+		return
+	}
+
+	id, err := strconv.Atoi(entry)
+
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("bad audit entry id, %v", err))
+		return
+	}
+
+	err = audit.Undo(id)
+
+	switch {
+	case errors.Is(err, ErrAuditEntryNotFound):
+		writeJSONError(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrCannotUndo):
+		writeJSONError(w, http.StatusConflict, err)
+	case err != nil:
+		writeJSONError(w, http.StatusInternalServerError, err)
+	default:
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// ledgerHandler lists the ledger, or one game's part of it with the game
+// query parameter, and records the entry POSTed to it as JSON.
+func (p *PlayerServer) ledgerHandler(w http.ResponseWriter, r *http.Request) {
+	ledger, ok := p.games.(LedgerStore)
+
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, errLedgerNotSupported)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		p.showLedger(w, r, ledger)
+	case http.MethodPost:
+		p.processLedgerEntry(w, r, ledger)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (p *PlayerServer) showLedger(w http.ResponseWriter, r *http.Request, ledger LedgerStore) {
+	entries, err := ledger.GetLedger()
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if game := r.URL.Query().Get("game"); game != "" {
+		id, err := strconv.Atoi(game)
+
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("bad game id, %v", err))
+			return
+		}
+
+		gameEntries := []LedgerEntry{}
+		for _, entry := range entries {
+			if entry.GameID == id {
+				gameEntries = append(gameEntries, entry)
+			}
+		}
+		entries = gameEntries
+	}
+
+	w.Header().Set("content-type", jsonContentType)
+	json.NewEncoder(w).Encode(entries)
+}
+
+func (p *PlayerServer) processLedgerEntry(w http.ResponseWriter, r *http.Request, ledger LedgerStore) {
+	var entry LedgerEntry
+
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("problem parsing ledger entry, %v", err))
+		return
+	}
+
+	recorded, err := ledger.RecordLedgerEntry(entry)
+
+	if errors.Is(err, ErrInvalidLedgerEntry) {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("content-type", jsonContentType)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(recorded)
+}
+
+func (p *PlayerServer) balancesHandler(w http.ResponseWriter, r *http.Request) {
+	ledger, ok := p.games.(LedgerStore)
+
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, errLedgerNotSupported)
+		return
+	}
+
+	balances, err := ledger.GetBalances()
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("content-type", jsonContentType)
+	json.NewEncoder(w).Encode(balances)
+}
+
+// settleUpHandler returns the fewest transfers that clear every balance.
+func (p *PlayerServer) settleUpHandler(w http.ResponseWriter, r *http.Request) {
+	ledger, ok := p.games.(LedgerStore)
+
+	if !ok {
+		writeJSONError(w, http.StatusNotImplemented, errLedgerNotSupported)
+		return
+	}
+
+	balances, err := ledger.GetBalances()
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	transfers, err := SettleUp(balances)
+
+	if errors.Is(err, ErrLedgerUnbalanced) {
+		writeJSONError(w, http.StatusConflict, err)
+		return
+	}
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("content-type", jsonContentType)
+	json.NewEncoder(w).Encode(transfers)
+}
+
+func (p *PlayerServer) gamesHandler(w http.ResponseWriter, r *http.Request) {
+	games, err := p.games.GetGames()
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("content-type", jsonContentType)
+	json.NewEncoder(w).Encode(games)
+}
+
+const csvContentType = "text/csv"
+
+// leagueCSVHandler returns the league as a CSV file for spreadsheets.
+func (p *PlayerServer) leagueCSVHandler(w http.ResponseWriter, r *http.Request) {
+	league, err := p.store.GetLeague()
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("content-type", csvContentType)
+	WriteLeague(w, league, CSVFormat)
+}
+
+// gamesCSVHandler returns the game history as a CSV file for spreadsheets.
+func (p *PlayerServer) gamesCSVHandler(w http.ResponseWriter, r *http.Request) {
+	games, err := p.games.GetGames()
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("content-type", csvContentType)
+	WriteGames(w, games, CSVFormat)
+}
+
+func (p *PlayerServer) gameHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Path[len("/games/"):])
+
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("bad game id, %v", err))
+		return
+	}
+
+	game, err := p.games.GetGame(id)
+
+	if errors.Is(err, ErrGameNotFound) {
+		writeJSONError(w, http.StatusNotFound, err)
+		return
+	}
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("content-type", jsonContentType)
+	json.NewEncoder(w).Encode(game)
+}
+
 func (p *PlayerServer) playGame(w http.ResponseWriter, r *http.Request) {
 	p.template.Execute(w, nil)
 }
@@ -83,16 +809,43 @@ var upgrader = websocket.Upgrader{
 func (p *PlayerServer) websocket(w http.ResponseWriter, r *http.Request) {
 	ws := newPlayerServerWS(w, r)
 
-	numberOfPlayersMsg := ws.WaitForMsg()
-	numberOfPlayers, _ := strconv.Atoi(numberOfPlayersMsg)
-	p.game.Start(numberOfPlayers, ws)
+	numberOfPlayers, players, err := parsePlayers(ws.WaitForMsg())
+
+	if err != nil {
+		fmt.Fprint(ws, BadPlayerInputErrMsg)
+		return
+	}
+
+	game, err := p.game.Start(numberOfPlayers, players, ws)
+
+	if err != nil {
+		log.Printf("problem starting game %v\n", err)
+		fmt.Fprintf(ws, "%s, %v", StartGameErrMsg, err)
+		return
+	}
 
 	winner := ws.WaitForMsg()
-	p.game.Finish(winner)
+
+	if err := game.Finish(winner, GameSource(r.RemoteAddr)); err != nil {
+		log.Printf("problem finishing game %v\n", err)
+		fmt.Fprintf(ws, "%s, %v", RecordWinErrMsg, err)
+	}
 }
 
-func (p *PlayerServer) showScore(w http.ResponseWriter, player string) {
-	score := p.store.GetPlayerScore(player)
+// showScore writes the player's score, or their stats as JSON when the
+// request accepts JSON.
+func (p *PlayerServer) showScore(w http.ResponseWriter, r *http.Request, player string) {
+	score, err := p.store.GetPlayerScore(player)
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), jsonContentType) {
+		p.showStats(w, player, score)
+		return
+	}
 
 	if score == 0 {
 		w.WriteHeader(http.StatusNotFound)
@@ -101,7 +854,47 @@ func (p *PlayerServer) showScore(w http.ResponseWriter, player string) {
 	fmt.Fprint(w, score)
 }
 
-func (p *PlayerServer) processWin(w http.ResponseWriter, player string) {
-	p.store.RecordWin(player)
+func (p *PlayerServer) showStats(w http.ResponseWriter, player string, score int) {
+	games, err := p.statsGames()
+
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if aliases, ok := StoreAs[AliasStore](p.store); ok {
+		if player, err = aliases.ResolvePlayer(player); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	stats := CalculatePlayerStats(player, score, games)
+
+	w.Header().Set("content-type", jsonContentType)
+
+	if stats.Wins == 0 && stats.GamesPlayed == 0 {
+		w.WriteHeader(http.StatusNotFound)
+	}
+
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (p *PlayerServer) processWin(w http.ResponseWriter, r *http.Request, player string) {
+	if err := p.storeFor(r).RecordWin(player); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	w.WriteHeader(http.StatusAccepted)
 }
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("content-type", jsonContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Error: err.Error()})
+}
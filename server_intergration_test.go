@@ -3,6 +3,7 @@ package poker_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
 	poker "github.com/ljones140/golang-player-webserver"
@@ -39,3 +40,68 @@ func TestRecordingWinsAndRetrievingThem(t *testing.T) {
 		poker.AssertLeague(t, got, want)
 	})
 }
+
+func TestRecordingWinsAndRetrievingThemFromEventLog(t *testing.T) {
+	database, cleanDatabase := createTempFile(t, "")
+	defer cleanDatabase()
+	store, _ := poker.NewEventLogPlayerStore(database)
+
+	server := mustMakePlayerServer(t, store, dummyGame)
+	player := "Pepper"
+
+	server.ServeHTTP(httptest.NewRecorder(), poker.NewPostWinRequest(player))
+	server.ServeHTTP(httptest.NewRecorder(), poker.NewPostWinRequest(player))
+
+	response := httptest.NewRecorder()
+	server.ServeHTTP(response, poker.NewGetLeagueRequest())
+
+	got := poker.GetLeagueFromResponse(t, response.Body)
+	want := []poker.Player{
+		{"Pepper", 2},
+	}
+	poker.AssertLeague(t, got, want)
+}
+
+func TestConcurrentlyRecordingWinsAndRetrievingThem(t *testing.T) {
+	database, cleanDatabase := createTempFile(t, `[]`)
+	defer cleanDatabase()
+	store, err := poker.NewFileSystemPlayerStore(database)
+	poker.AssertNoError(t, err)
+
+	server := mustMakePlayerServer(t, store, dummyGame)
+	players := []string{"Pepper", "Floyd", "Cleo"}
+	winsEach := 20
+
+	var wg sync.WaitGroup
+	for _, player := range players {
+		for i := 0; i < winsEach; i++ {
+			wg.Add(3)
+			go func(player string) {
+				defer wg.Done()
+				server.ServeHTTP(httptest.NewRecorder(), poker.NewPostWinRequest(player))
+			}(player)
+			go func(player string) {
+				defer wg.Done()
+				server.ServeHTTP(httptest.NewRecorder(), poker.NewGetScoreRequest(player))
+			}(player)
+			go func() {
+				defer wg.Done()
+				response := httptest.NewRecorder()
+				server.ServeHTTP(response, poker.NewGetLeagueRequest())
+				poker.GetLeagueFromResponse(t, response.Body)
+			}()
+		}
+	}
+	wg.Wait()
+
+	for _, player := range players {
+		poker.AssertScore(t, store, player, winsEach)
+	}
+
+	league := poker.MustGetLeague(t, store)
+	league[0].Wins = 0
+
+	if poker.MustGetLeague(t, store)[0].Wins != winsEach {
+		t.Error("changing the returned league should not change the store")
+	}
+}
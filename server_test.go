@@ -1,6 +1,9 @@
 package poker_test
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -13,8 +16,9 @@ import (
 )
 
 var (
-	dummyGame = &poker.GameSpy{}
-	tenMS     = 10 * time.Millisecond
+	dummyGame      = &poker.GameSpy{}
+	dummyGameStore = &poker.StubGameStore{}
+	tenMS          = 10 * time.Millisecond
 )
 
 func TestGETPlayers(t *testing.T) {
@@ -65,7 +69,7 @@ func TestLeague(t *testing.T) {
 			{"Cleo", 14},
 		}
 
-		store := poker.StubPlayerStore{nil, nil, wantedLeague}
+		store := poker.StubPlayerStore{League: wantedLeague}
 		server := mustMakePlayerServer(t, &store, dummyGame)
 
 		request := poker.NewGetLeagueRequest()
@@ -105,6 +109,83 @@ func TestStoreWins(t *testing.T) {
 	})
 }
 
+func TestStoreErrors(t *testing.T) {
+	store := &poker.StubPlayerStore{Err: errors.New("disk full")}
+	server := mustMakePlayerServer(t, store, dummyGame)
+
+	cases := map[string]*http.Request{
+		"POST /players/{name}": poker.NewPostWinRequest("Pepper"),
+		"GET /players/{name}":  poker.NewGetScoreRequest("Pepper"),
+		"GET /league":          poker.NewGetLeagueRequest(),
+	}
+
+	for name, request := range cases {
+		t.Run(name+" returns a 500 with a JSON error", func(t *testing.T) {
+			response := httptest.NewRecorder()
+
+			server.ServeHTTP(response, request)
+
+			assertStatus(t, response, http.StatusInternalServerError)
+			poker.AssertContentType(t, response, "application/json")
+			assertJSONError(t, response.Body, "disk full")
+		})
+	}
+}
+
+func TestGames(t *testing.T) {
+	started := time.Date(2026, 10, 13, 19, 0, 0, 0, time.UTC)
+	games := &poker.StubGameStore{Games: []poker.GameRecord{
+		{ID: 1, StartedAt: started, FinishedAt: started.Add(2 * time.Hour), NumberOfPlayers: 5, Winner: "Ruth", HighestBlind: 800},
+		{ID: 2, StartedAt: started.Add(3 * time.Hour), FinishedAt: started.Add(4 * time.Hour), NumberOfPlayers: 3, Winner: "Cleo", HighestBlind: 400},
+	}}
+	server := mustMakePlayerServerWithGames(t, dummyPlayerStore, games, dummyGame)
+
+	t.Run("GET /games lists past games as JSON", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, poker.NewGetGamesRequest())
+
+		assertStatus(t, response, http.StatusOK)
+		poker.AssertContentType(t, response, "application/json")
+
+		var got []poker.GameRecord
+		if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+			t.Fatalf("could not parse games response, %v", err)
+		}
+		assertGames(t, got, games.Games)
+	})
+
+	t.Run("GET /games/{id} returns one game", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, poker.NewGetGameRequest("2"))
+
+		assertStatus(t, response, http.StatusOK)
+
+		var got poker.GameRecord
+		if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+			t.Fatalf("could not parse game response, %v", err)
+		}
+		assertGames(t, []poker.GameRecord{got}, games.Games[1:])
+	})
+
+	t.Run("GET /games/{id} returns 404 for an unknown game", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, poker.NewGetGameRequest("99"))
+
+		assertStatus(t, response, http.StatusNotFound)
+	})
+
+	t.Run("GET /games/{id} returns 400 for a bad id", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, poker.NewGetGameRequest("tuesday"))
+
+		assertStatus(t, response, http.StatusBadRequest)
+	})
+}
+
 func TestGame(t *testing.T) {
 	t.Run("GET /game returns a 200", func(t *testing.T) {
 		server := mustMakePlayerServer(t, &poker.StubPlayerStore{}, dummyGame)
@@ -132,6 +213,7 @@ func TestGame(t *testing.T) {
 
 		assertGameStartedWith(t, game, 3)
 		assertFinishCalledWith(t, game, winner)
+		assertFinishedFrom(t, game, poker.SourceGame)
 		within(t, tenMS, func() { assertWebsocketGotMsg(t, ws, wantedBlindAlert) })
 	})
 }
@@ -144,6 +226,36 @@ func assertStatus(t testing.TB, response *httptest.ResponseRecorder, want int) {
 	}
 }
 
+func assertGames(t testing.TB, got, want []poker.GameRecord) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d games want %d", len(got), len(want))
+	}
+
+	for i := range want {
+		if got[i].ID != want[i].ID || got[i].Winner != want[i].Winner || !got[i].StartedAt.Equal(want[i].StartedAt) || got[i].HighestBlind != want[i].HighestBlind {
+			t.Errorf("got game %+v want %+v", got[i], want[i])
+		}
+	}
+}
+
+func assertJSONError(t testing.TB, body io.Reader, want string) {
+	t.Helper()
+
+	var got struct {
+		Error string `json:"error"`
+	}
+
+	if err := json.NewDecoder(body).Decode(&got); err != nil {
+		t.Fatalf("could not parse error response, %v", err)
+	}
+
+	if got.Error != want {
+		t.Errorf("got error %q want %q", got.Error, want)
+	}
+}
+
 func assertWebsocketGotMsg(t *testing.T, ws *websocket.Conn, want string) {
 	_, gotMessage, _ := ws.ReadMessage()
 	if string(gotMessage) != want {
@@ -152,7 +264,11 @@ func assertWebsocketGotMsg(t *testing.T, ws *websocket.Conn, want string) {
 }
 
 func mustMakePlayerServer(t *testing.T, store poker.PlayerStore, game poker.Game) *poker.PlayerServer {
-	server, err := poker.NewPlayerServer(store, game)
+	return mustMakePlayerServerWithGames(t, store, dummyGameStore, game)
+}
+
+func mustMakePlayerServerWithGames(t *testing.T, store poker.PlayerStore, games poker.GameStore, game poker.Game) *poker.PlayerServer {
+	server, err := poker.NewPlayerServer(store, games, game)
 	if err != nil {
 		t.Fatal("problem creating player server", err)
 	}
@@ -0,0 +1,221 @@
+package poker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+const (
+	snapshotsSuffix      = ".snapshots"
+	snapshotLayout       = "20060102T150405.000000000Z"
+	snapshotExt          = ".json"
+	defaultKeepSnapshots = 10
+)
+
+// SnapshotStore is implemented by player stores that can take a consistent
+// copy of themselves while in use, and be restored from one.
+type SnapshotStore interface {
+	TakeSnapshot() (Snapshot, error)
+	GetSnapshots() ([]Snapshot, error)
+	Restore(name string) error
+}
+
+// Snapshot is a copy of the database taken at TakenAt. Name is the time it
+// was taken, which is also how it's asked for when restoring.
+type Snapshot struct {
+	Name    string
+	Path    string
+	TakenAt time.Time
+	Size    int64
+}
+
+// TakeSnapshot writes the database as it stands to a new file in the
+// snapshots directory next to it, then removes the oldest snapshots beyond
+// KeepSnapshots. It holds the database's lock file while it reads, so the copy
+// never catches another process part way through a change.
+func (f *FileSystemPlayerStore) TakeSnapshot() (Snapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.lock != nil {
+		if err := f.lock.Lock(); err != nil {
+			return Snapshot{}, err
+		}
+		defer f.lock.Unlock()
+
+		if err := f.reloadIfStale(); err != nil {
+			return Snapshot{}, err
+		}
+	}
+
+	return f.takeSnapshot()
+}
+
+func (f *FileSystemPlayerStore) takeSnapshot() (Snapshot, error) {
+	data, err := json.Marshal(f.db)
+
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("problem encoding snapshot, %v", err)
+	}
+
+	if data, err = f.cipher.seal(data); err != nil {
+		return Snapshot{}, err
+	}
+
+	dir := f.snapshotsDir()
+
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return Snapshot{}, fmt.Errorf("problem creating snapshots directory %s, %v", dir, err)
+	}
+
+	takenAt := time.Now().UTC()
+	name := takenAt.Format(snapshotLayout)
+	path := filepath.Join(dir, name+snapshotExt)
+
+	if err := writeFileAtomically(path, data); err != nil {
+		return Snapshot{}, err
+	}
+
+	if err := f.pruneSnapshots(); err != nil {
+		return Snapshot{}, err
+	}
+
+	return Snapshot{Name: name, Path: path, TakenAt: takenAt, Size: int64(len(data))}, nil
+}
+
+// GetSnapshots returns the snapshots that have been kept, newest first.
+func (f *FileSystemPlayerStore) GetSnapshots() ([]Snapshot, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.snapshots()
+}
+
+func (f *FileSystemPlayerStore) snapshots() ([]Snapshot, error) {
+	dir := f.snapshotsDir()
+	entries, err := os.ReadDir(dir)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return []Snapshot{}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("problem reading snapshots directory %s, %v", dir, err)
+	}
+
+	snapshots := []Snapshot{}
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), snapshotExt)
+		takenAt, err := time.Parse(snapshotLayout, name)
+
+		if !ok || err != nil || !entry.Type().IsRegular() {
+			continue
+		}
+
+		info, err := entry.Info()
+
+		if err != nil {
+			return nil, fmt.Errorf("problem reading snapshot %s, %v", entry.Name(), err)
+		}
+
+		snapshots = append(snapshots, Snapshot{Name: name, Path: filepath.Join(dir, entry.Name()), TakenAt: takenAt, Size: info.Size()})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].TakenAt.After(snapshots[j].TakenAt)
+	})
+
+	return snapshots, nil
+}
+
+func (f *FileSystemPlayerStore) pruneSnapshots() error {
+	if f.KeepSnapshots <= 0 {
+		return nil
+	}
+
+	snapshots, err := f.snapshots()
+
+	if err != nil {
+		return err
+	}
+
+	for len(snapshots) > f.KeepSnapshots {
+		oldest := snapshots[len(snapshots)-1]
+
+		if err := os.Remove(oldest.Path); err != nil {
+			return fmt.Errorf("problem removing old snapshot %s, %v", oldest.Path, err)
+		}
+
+		snapshots = snapshots[:len(snapshots)-1]
+	}
+
+	return nil
+}
+
+// Restore replaces the live database with the named snapshot, migrating it
+// first if it was taken at an older schema version. The live database is
+// snapshotted before it's replaced, so a restore can itself be reverted by
+// restoring that snapshot. The restore is logged after the snapshot's own
+// audit log.
+func (f *FileSystemPlayerStore) Restore(name string) error {
+	snapshot, err := f.findSnapshot(name)
+
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(snapshot.Path)
+
+	if err != nil {
+		return fmt.Errorf("problem reading snapshot %s, %v", snapshot.Path, err)
+	}
+
+	if data, err = f.cipher.open(data); err != nil {
+		return fmt.Errorf("problem reading snapshot %s, %w", snapshot.Path, err)
+	}
+
+	restored, _, err := decodePlayerDB(data)
+
+	if err != nil {
+		return fmt.Errorf("problem loading snapshot %s, %v", snapshot.Path, err)
+	}
+
+	return f.update(func(db *playerDB) error {
+		if _, err := f.takeSnapshot(); err != nil {
+			return fmt.Errorf("problem snapshotting the database before restoring, %v", err)
+		}
+
+		*db = restored
+		db.log(auditRecord{Action: restoreAction, Reason: "restored snapshot " + snapshot.Name})
+		return nil
+	})
+}
+
+func (f *FileSystemPlayerStore) findSnapshot(name string) (Snapshot, error) {
+	snapshots, err := f.GetSnapshots()
+
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	for _, snapshot := range snapshots {
+		if snapshot.Name == name {
+			return snapshot, nil
+		}
+	}
+
+	return Snapshot{}, fmt.Errorf("%w: %s", ErrSnapshotNotFound, name)
+}
+
+func (f *FileSystemPlayerStore) snapshotsDir() string {
+	return f.tape.File.Name() + snapshotsSuffix
+}
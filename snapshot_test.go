@@ -0,0 +1,174 @@
+package poker_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+const adminToken = "let-me-in"
+
+func TestFileSystemPlayerStoreSnapshots(t *testing.T) {
+	t.Run("takes a snapshot that can be restored over later changes", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		snapshot, err := store.TakeSnapshot()
+		poker.AssertNoError(t, err)
+
+		poker.MustRecordWin(t, store, "Chris")
+		poker.AssertNoError(t, store.DeletePlayer("Cleo", "left the club"))
+
+		poker.AssertNoError(t, store.Restore(snapshot.Name))
+
+		poker.AssertScore(t, store, "Chris", 33)
+		poker.AssertScore(t, store, "Cleo", 10)
+
+		if entry := lastAuditEntry(t, store); entry.Action != "restore" {
+			t.Errorf("got audit entry %+v want the restore", entry)
+		}
+	})
+
+	t.Run("snapshots the live data before restoring over it", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		snapshot, err := store.TakeSnapshot()
+		poker.AssertNoError(t, err)
+		poker.MustRecordWin(t, store, "Chris")
+
+		poker.AssertNoError(t, store.Restore(snapshot.Name))
+
+		snapshots, err := store.GetSnapshots()
+		poker.AssertNoError(t, err)
+
+		if len(snapshots) != 2 {
+			t.Fatalf("got %d snapshots want %d", len(snapshots), 2)
+		}
+
+		poker.AssertNoError(t, store.Restore(snapshots[0].Name))
+		poker.AssertScore(t, store, "Chris", 34)
+	})
+
+	t.Run("keeps only the newest snapshots", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+		store.KeepSnapshots = 2
+
+		var taken []poker.Snapshot
+		for i := 0; i < 3; i++ {
+			snapshot, err := store.TakeSnapshot()
+			poker.AssertNoError(t, err)
+			taken = append(taken, snapshot)
+		}
+
+		snapshots, err := store.GetSnapshots()
+		poker.AssertNoError(t, err)
+
+		if len(snapshots) != 2 || snapshots[0].Name != taken[2].Name || snapshots[1].Name != taken[1].Name {
+			t.Errorf("got snapshots %+v want the last two taken", snapshots)
+		}
+
+		if _, err := os.Stat(taken[0].Path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected the oldest snapshot to be removed, %v", err)
+		}
+	})
+
+	t.Run("sees changes made by another store sharing the file", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, "")
+		defer cleanDatabase()
+
+		store, closeStore, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeStore()
+
+		other, closeOther, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		poker.AssertNoError(t, err)
+		defer closeOther()
+
+		poker.MustRecordWin(t, other, "Chris")
+
+		snapshot, err := store.TakeSnapshot()
+		poker.AssertNoError(t, err)
+		poker.MustRecordWin(t, other, "Chris")
+
+		poker.AssertNoError(t, store.Restore(snapshot.Name))
+		poker.AssertScore(t, store, "Chris", 1)
+	})
+
+	t.Run("returns an error restoring a snapshot that doesn't exist", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		if err := store.Restore("../../etc/passwd"); !errors.Is(err, poker.ErrSnapshotNotFound) {
+			t.Errorf("got error %v want %v", err, poker.ErrSnapshotNotFound)
+		}
+	})
+}
+
+func TestSnapshotsOverHTTP(t *testing.T) {
+	store := mustMakeAliasStore(t)
+	server, _ := poker.NewPlayerServer(store, &poker.StubGameStore{}, dummyGame)
+	server.AdminToken = adminToken
+
+	t.Run("POST /admin/snapshots takes a snapshot and GET lists it", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewTakeSnapshotRequest(adminToken))
+		assertStatus(t, response, http.StatusCreated)
+
+		response = httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetSnapshotsRequest(adminToken))
+
+		var snapshots []poker.Snapshot
+		decodeJSON(t, response, &snapshots)
+
+		if len(snapshots) != 1 {
+			t.Errorf("got snapshots %+v want one", snapshots)
+		}
+	})
+
+	t.Run("POST /admin/snapshots/{name}/restore restores it", func(t *testing.T) {
+		snapshot, err := store.TakeSnapshot()
+		poker.AssertNoError(t, err)
+		poker.MustRecordWin(t, store, "Chris")
+
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewRestoreRequest(snapshot.Name, adminToken))
+
+		assertStatus(t, response, http.StatusAccepted)
+		poker.AssertScore(t, store, "Chris", 33)
+	})
+
+	t.Run("returns 404 restoring a snapshot that doesn't exist", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewRestoreRequest("yesterday", adminToken))
+
+		assertStatus(t, response, http.StatusNotFound)
+	})
+
+	for _, token := range []string{"", "let-me-in-please"} {
+		t.Run("returns 401 for token "+token, func(t *testing.T) {
+			response := httptest.NewRecorder()
+			server.ServeHTTP(response, poker.NewTakeSnapshotRequest(token))
+
+			assertStatus(t, response, http.StatusUnauthorized)
+		})
+	}
+
+	t.Run("returns 403 while no admin token is set", func(t *testing.T) {
+		server, _ := poker.NewPlayerServer(store, &poker.StubGameStore{}, dummyGame)
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetSnapshotsRequest(adminToken))
+
+		assertStatus(t, response, http.StatusForbidden)
+	})
+
+	t.Run("returns 501 when the store can't be snapshotted", func(t *testing.T) {
+		server, _ := poker.NewPlayerServer(&poker.StubPlayerStore{}, &poker.StubGameStore{}, dummyGame)
+		server.AdminToken = adminToken
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetSnapshotsRequest(adminToken))
+
+		assertStatus(t, response, http.StatusNotImplemented)
+	})
+}
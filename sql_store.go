@@ -0,0 +1,190 @@
+package poker
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqlSchema = `
+CREATE TABLE IF NOT EXISTS players (
+	id   INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS wins (
+	id          INTEGER PRIMARY KEY,
+	player_id   INTEGER NOT NULL REFERENCES players(id),
+	recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS wins_by_player ON wins(player_id);
+
+CREATE INDEX IF NOT EXISTS players_by_key ON players(name COLLATE NOCASE);
+`
+
+// SQLPlayerStore keeps players and their wins in an embedded SQLite
+// database file.
+type SQLPlayerStore struct {
+	db *sql.DB
+}
+
+func SQLPlayerStoreFromFile(path string) (*SQLPlayerStore, func(), error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("problem opening %s %v", path, err)
+	}
+
+	closeFunc := func() {
+		db.Close()
+	}
+
+	store, err := NewSQLPlayerStore(db)
+
+	if err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("problem creating sql player store, %v ", err)
+	}
+
+	return store, closeFunc, nil
+}
+
+func NewSQLPlayerStore(db *sql.DB) (*SQLPlayerStore, error) {
+	// SQLite allows a single writer, queueing on one connection avoids
+	// busy errors between the server's goroutines.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqlSchema); err != nil {
+		return nil, fmt.Errorf("problem creating tables, %v", err)
+	}
+
+	return &SQLPlayerStore{db: db}, nil
+}
+
+func (s *SQLPlayerStore) GetLeague() (League, error) {
+	rows, err := s.db.Query(`
+		SELECT p.name, COUNT(w.id) AS total
+		FROM players p JOIN wins w ON w.player_id = p.id
+		GROUP BY p.id
+		ORDER BY total DESC, p.name`)
+
+	if err != nil {
+		return nil, fmt.Errorf("problem querying league, %v", err)
+	}
+	defer rows.Close()
+
+	var league League
+	for rows.Next() {
+		var player Player
+		if err := rows.Scan(&player.Name, &player.Wins); err != nil {
+			return nil, fmt.Errorf("problem reading league, %v", err)
+		}
+		league = append(league, player)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("problem reading league, %v", err)
+	}
+
+	return league, nil
+}
+
+func (s *SQLPlayerStore) GetPlayerScore(name string) (int, error) {
+	var wins int
+
+	err := s.db.QueryRow(`
+		SELECT COUNT(w.id)
+		FROM players p JOIN wins w ON w.player_id = p.id
+		WHERE p.name = ? COLLATE NOCASE`, NormalisePlayerName(name)).Scan(&wins)
+
+	if err != nil {
+		return 0, fmt.Errorf("problem querying score for %s, %v", name, err)
+	}
+
+	return wins, nil
+}
+
+func (s *SQLPlayerStore) RecordWin(name string) error {
+	return s.recordWins(name, 1)
+}
+
+func (s *SQLPlayerStore) recordWins(name string, wins int) error {
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return fmt.Errorf("problem starting transaction, %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := recordWinsTx(tx, name, wins); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func recordWinsTx(tx *sql.Tx, name string, wins int) error {
+	name = NormalisePlayerName(name)
+
+	_, err := tx.Exec(`
+		INSERT INTO players (name) SELECT ?1
+		WHERE NOT EXISTS (SELECT 1 FROM players WHERE name = ?1 COLLATE NOCASE)`, name)
+
+	if err != nil {
+		return fmt.Errorf("problem adding player %s, %v", name, err)
+	}
+
+	for i := 0; i < wins; i++ {
+		if _, err := tx.Exec(`INSERT INTO wins (player_id) SELECT MIN(id) FROM players WHERE name = ? COLLATE NOCASE`, name); err != nil {
+			return fmt.Errorf("problem recording win for %s, %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// ImportFromFile copies the all-time league in a game.db.json style file into
+// an empty store and returns the number of players imported.
+func (s *SQLPlayerStore) ImportFromFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return 0, fmt.Errorf("problem reading %s, %v", path, err)
+	}
+
+	db, _, err := decodePlayerDB(data)
+
+	if err != nil {
+		return 0, fmt.Errorf("problem loading %s, %v", path, err)
+	}
+
+	league := db.allTime()
+
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return 0, fmt.Errorf("problem starting transaction, %v", err)
+	}
+	defer tx.Rollback()
+
+	var existing int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM players`).Scan(&existing); err != nil {
+		return 0, fmt.Errorf("problem counting players, %v", err)
+	}
+
+	if existing > 0 {
+		return 0, errors.New("refusing to import into a store that already has players")
+	}
+
+	for _, player := range league {
+		if err := recordWinsTx(tx, player.Name, player.Wins); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(league), tx.Commit()
+}
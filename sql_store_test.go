@@ -0,0 +1,123 @@
+package poker_test
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func TestSQLPlayerStore(t *testing.T) {
+	t.Run("records wins and looks up scores", func(t *testing.T) {
+		store := mustMakeSQLPlayerStore(t, tempDBPath(t))
+
+		poker.MustRecordWin(t, store, "Chris")
+		poker.MustRecordWin(t, store, "Chris")
+		poker.MustRecordWin(t, store, "Cleo")
+
+		poker.AssertScore(t, store, "Chris", 2)
+		poker.AssertScore(t, store, "Cleo", 1)
+		poker.AssertScore(t, store, "Nobody", 0)
+	})
+
+	t.Run("orders the league by wins", func(t *testing.T) {
+		store := mustMakeSQLPlayerStore(t, tempDBPath(t))
+
+		poker.MustRecordWin(t, store, "Cleo")
+		poker.MustRecordWin(t, store, "Chris")
+		poker.MustRecordWin(t, store, "Chris")
+
+		want := []poker.Player{
+			{"Chris", 2},
+			{"Cleo", 1},
+		}
+		poker.AssertLeague(t, poker.MustGetLeague(t, store), want)
+	})
+
+	t.Run("keeps wins across reopening the file", func(t *testing.T) {
+		path := tempDBPath(t)
+
+		store := mustMakeSQLPlayerStore(t, path)
+		poker.MustRecordWin(t, store, "Chris")
+
+		reopened := mustMakeSQLPlayerStore(t, path)
+		poker.AssertScore(t, reopened, "Chris", 1)
+	})
+
+	t.Run("imports a json database", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `[
+      {"Name": "Cleo", "Wins": 10},
+      {"Name": "Chris", "Wins": 33}]`)
+		defer cleanDatabase()
+
+		store := mustMakeSQLPlayerStore(t, tempDBPath(t))
+
+		imported, err := store.ImportFromFile(database.Name())
+		poker.AssertNoError(t, err)
+
+		if imported != 2 {
+			t.Errorf("got %d players imported want %d", imported, 2)
+		}
+
+		want := []poker.Player{
+			{"Chris", 33},
+			{"Cleo", 10},
+		}
+		poker.AssertLeague(t, poker.MustGetLeague(t, store), want)
+	})
+
+	t.Run("refuses to import twice", func(t *testing.T) {
+		database, cleanDatabase := createTempFile(t, `[{"Name": "Cleo", "Wins": 10}]`)
+		defer cleanDatabase()
+
+		store := mustMakeSQLPlayerStore(t, tempDBPath(t))
+
+		_, err := store.ImportFromFile(database.Name())
+		poker.AssertNoError(t, err)
+
+		_, err = store.ImportFromFile(database.Name())
+
+		if err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+		poker.AssertScore(t, store, "Cleo", 10)
+	})
+}
+
+func TestRecordingWinsAndRetrievingThemFromSQL(t *testing.T) {
+	store := mustMakeSQLPlayerStore(t, tempDBPath(t))
+
+	server := mustMakePlayerServer(t, store, dummyGame)
+	player := "Pepper"
+
+	server.ServeHTTP(httptest.NewRecorder(), poker.NewPostWinRequest(player))
+	server.ServeHTTP(httptest.NewRecorder(), poker.NewPostWinRequest(player))
+
+	response := httptest.NewRecorder()
+	server.ServeHTTP(response, poker.NewGetLeagueRequest())
+
+	got := poker.GetLeagueFromResponse(t, response.Body)
+	want := []poker.Player{
+		{"Pepper", 2},
+	}
+	poker.AssertLeague(t, got, want)
+}
+
+func tempDBPath(t testing.TB) string {
+	t.Helper()
+	return filepath.Join(t.TempDir(), "game.db.sqlite")
+}
+
+func mustMakeSQLPlayerStore(t testing.TB, path string) *poker.SQLPlayerStore {
+	t.Helper()
+
+	store, closeStore, err := poker.SQLPlayerStoreFromFile(path)
+
+	if err != nil {
+		t.Fatalf("could not open sql player store %v", err)
+	}
+	t.Cleanup(closeStore)
+
+	return store
+}
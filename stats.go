@@ -0,0 +1,140 @@
+package poker
+
+import (
+	"sort"
+	"time"
+)
+
+// PlayerStats are a player's results. Wins is taken from the player store,
+// everything else is worked out from the games in the game history that were
+// started with the players' names. Rating is InitialRating until the player
+// has played a rated game. Rank is only set once the stats are ranked with
+// RankStats.
+type PlayerStats struct {
+	Rank          int
+	Name          string
+	Wins          int
+	GamesPlayed   int
+	Losses        int
+	WinRate       float64
+	CurrentStreak int
+	LongestStreak int
+	LastPlayed    *time.Time
+	LastWon       *time.Time
+	Rating        float64
+}
+
+// CalculateStats returns the stats of every player in the league, in league
+// order.
+func CalculateStats(league League, games []GameRecord) []PlayerStats {
+	results := gameResults(games)
+	stats := make([]PlayerStats, len(league))
+
+	for i, player := range league {
+		stats[i] = results.statsFor(player.Name, player.Wins)
+	}
+
+	return stats
+}
+
+// CalculatePlayerStats returns the stats of one player who has wins in the
+// player store.
+func CalculatePlayerStats(name string, wins int, games []GameRecord) PlayerStats {
+	return gameResults(games).statsFor(NormalisePlayerName(name), wins)
+}
+
+type playerResults map[string]*PlayerStats
+
+func gameResults(games []GameRecord) playerResults {
+	played := make([]GameRecord, len(games))
+	copy(played, games)
+
+	sort.SliceStable(played, func(i, j int) bool {
+		return played[i].FinishedAt.Before(played[j].FinishedAt)
+	})
+
+	results := playerResults{}
+
+	for _, game := range played {
+		counted := map[string]bool{}
+
+		for _, player := range game.Players {
+			// names resolved to the same player count once
+			if counted[playerKey(player)] {
+				continue
+			}
+			counted[playerKey(player)] = true
+
+			result := results.find(player)
+			won := playerKey(player) == playerKey(game.Winner)
+			finishedAt := game.FinishedAt
+
+			result.GamesPlayed++
+			result.LastPlayed = &finishedAt
+
+			for _, change := range game.Ratings {
+				if playerKey(change.Player) == playerKey(player) {
+					result.Rating = change.After
+				}
+			}
+
+			if !won {
+				result.Losses++
+				result.CurrentStreak = 0
+				continue
+			}
+
+			result.LastWon = &finishedAt
+			result.CurrentStreak++
+			if result.CurrentStreak > result.LongestStreak {
+				result.LongestStreak = result.CurrentStreak
+			}
+		}
+	}
+
+	for _, result := range results {
+		result.WinRate = float64(result.GamesPlayed-result.Losses) / float64(result.GamesPlayed)
+	}
+
+	return results
+}
+
+// resolvePlayers returns copies of games with the players and the winner
+// named as resolve names them, so the games a player played under an alias, or
+// under a name since merged into theirs, count as theirs.
+func resolvePlayers(games []GameRecord, resolve func(name string) string) []GameRecord {
+	resolved := make([]GameRecord, len(games))
+
+	for i, game := range games {
+		players := make([]string, len(game.Players))
+		for j, player := range game.Players {
+			players[j] = resolve(player)
+		}
+
+		game.Players = players
+		game.Winner = resolve(game.Winner)
+		resolved[i] = game
+	}
+
+	return resolved
+}
+
+func (r playerResults) find(name string) *PlayerStats {
+	key := playerKey(name)
+
+	if _, ok := r[key]; !ok {
+		r[key] = &PlayerStats{Rating: InitialRating}
+	}
+	return r[key]
+}
+
+func (r playerResults) statsFor(name string, wins int) PlayerStats {
+	stats := PlayerStats{Rating: InitialRating}
+	if result, ok := r[playerKey(name)]; ok {
+		stats = *result
+	}
+
+	stats.Name = name
+	stats.Wins = wins
+	return stats
+}
@@ -0,0 +1,163 @@
+package poker_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+var statsStart = time.Date(2026, time.October, 1, 20, 0, 0, 0, time.UTC)
+
+func statsGame(day int, winner string, players ...string) poker.GameRecord {
+	finishedAt := statsStart.AddDate(0, 0, day)
+	return poker.GameRecord{ID: day + 1, StartedAt: finishedAt.Add(-time.Hour), FinishedAt: finishedAt, NumberOfPlayers: len(players), Players: players, Winner: winner}
+}
+
+var statsGames = []poker.GameRecord{
+	statsGame(0, "Chris", "Chris", "Cleo"),
+	statsGame(1, "Chris", "Chris", "Cleo"),
+	statsGame(2, "Cleo", "Chris", "Cleo", "Ruth"),
+	statsGame(3, "Chris", "Chris", "cleo"),
+	{ID: 5, FinishedAt: statsStart.AddDate(0, 0, 4), NumberOfPlayers: 4, Winner: "Chris"},
+}
+
+func TestCalculateStats(t *testing.T) {
+	league := poker.League{{"Chris", 4}, {"Cleo", 1}}
+
+	stats := poker.CalculateStats(league, statsGames)
+
+	if len(stats) != 2 {
+		t.Fatalf("got %d players want %d", len(stats), 2)
+	}
+
+	chris, cleo := stats[0], stats[1]
+
+	assertStats(t, chris, poker.PlayerStats{Name: "Chris", Wins: 4, GamesPlayed: 4, Losses: 1, WinRate: 0.75, CurrentStreak: 1, LongestStreak: 2, Rating: poker.InitialRating})
+	assertStats(t, cleo, poker.PlayerStats{Name: "Cleo", Wins: 1, GamesPlayed: 4, Losses: 3, WinRate: 0.25, CurrentStreak: 0, LongestStreak: 1, Rating: poker.InitialRating})
+
+	if want := statsStart.AddDate(0, 0, 3); chris.LastPlayed == nil || !chris.LastPlayed.Equal(want) {
+		t.Errorf("got last played %v want %v", chris.LastPlayed, want)
+	}
+}
+
+func TestCalculatePlayerStats(t *testing.T) {
+	t.Run("counts games played without a win", func(t *testing.T) {
+		ruth := poker.CalculatePlayerStats("ruth", 0, statsGames)
+
+		assertStats(t, ruth, poker.PlayerStats{Name: "ruth", GamesPlayed: 1, Losses: 1, Rating: poker.InitialRating})
+	})
+
+	t.Run("has no results for a player missing from the history", func(t *testing.T) {
+		tom := poker.CalculatePlayerStats("Tom", 2, statsGames)
+
+		assertStats(t, tom, poker.PlayerStats{Name: "Tom", Wins: 2, Rating: poker.InitialRating})
+
+		if tom.LastPlayed != nil {
+			t.Errorf("got last played %v want nil", tom.LastPlayed)
+		}
+	})
+}
+
+func TestStatsOverHTTP(t *testing.T) {
+	store := &poker.StubPlayerStore{
+		Scores: map[string]int{"Chris": 4},
+		League: []poker.Player{{"Chris", 4}, {"Cleo", 1}},
+	}
+	server, _ := poker.NewPlayerServer(store, &poker.StubGameStore{Games: statsGames}, dummyGame)
+
+	t.Run("GET /league includes each player's stats", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetLeagueRequest())
+
+		var stats []poker.PlayerStats
+		if err := json.NewDecoder(response.Body).Decode(&stats); err != nil {
+			t.Fatalf("could not parse league, %v", err)
+		}
+
+		if len(stats) != 2 || stats[0].GamesPlayed != 4 || stats[1].Losses != 3 {
+			t.Errorf("got league %+v", stats)
+		}
+	})
+
+	t.Run("GET /players/{name} returns stats as JSON when asked for", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetStatsRequest("Chris"))
+
+		assertStatus(t, response, http.StatusOK)
+		poker.AssertContentType(t, response, "application/json")
+
+		var stats poker.PlayerStats
+		if err := json.NewDecoder(response.Body).Decode(&stats); err != nil {
+			t.Fatalf("could not parse stats, %v", err)
+		}
+
+		if stats.Wins != 4 || stats.GamesPlayed != 4 || stats.WinRate != 0.75 {
+			t.Errorf("got stats %+v", stats)
+		}
+	})
+
+	t.Run("GET /players/{name} still returns the plain score by default", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetScoreRequest("Chris"))
+
+		poker.AssertResponseBody(t, response.Body.String(), "4")
+	})
+
+	t.Run("GET /players/{name} returns 404 stats for an unknown player", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetStatsRequest("Tom"))
+
+		assertStatus(t, response, http.StatusNotFound)
+	})
+}
+
+func TestStatsFollowAliasesAndMerges(t *testing.T) {
+	store := mustMakeAliasStore(t)
+	poker.AssertNoError(t, store.AddAlias("Chris", "CJ"))
+
+	games := &poker.StubGameStore{Games: []poker.GameRecord{
+		statsGame(0, "CJ", "CJ", "Cleo"),
+		statsGame(1, "Cleo", "Chris", "Cleo"),
+	}}
+	server := mustMakePlayerServerWithGames(t, store, games, dummyGame)
+
+	t.Run("counts the games played under an alias", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetStatsRequest("CJ"))
+
+		var stats poker.PlayerStats
+		decodeJSON(t, response, &stats)
+
+		if stats.Name != "Chris" || stats.GamesPlayed != 2 || stats.Losses != 1 {
+			t.Errorf("got stats %+v want Chris on both games", stats)
+		}
+	})
+
+	t.Run("counts a game once for players since merged", func(t *testing.T) {
+		poker.AssertNoError(t, store.MergePlayers("Cleo", "Chris"))
+
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetLeagueRequest())
+
+		var stats []poker.PlayerStats
+		decodeJSON(t, response, &stats)
+
+		if len(stats) != 1 || stats[0].GamesPlayed != 2 || stats[0].WinRate != 1 {
+			t.Errorf("got league %+v want Chris winning both games", stats)
+		}
+	})
+}
+
+func assertStats(t testing.TB, got, want poker.PlayerStats) {
+	t.Helper()
+
+	got.LastPlayed = nil
+	got.LastWon = nil
+	if got != want {
+		t.Errorf("got stats %+v want %+v", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package poker
+
+import "fmt"
+
+const (
+	JSONBackend     = "json"
+	EventLogBackend = "eventlog"
+	SQLiteBackend   = "sqlite"
+)
+
+var defaultDBFiles = map[string]string{
+	JSONBackend:     "game.db.json",
+	EventLogBackend: "game.db.log",
+	SQLiteBackend:   "game.db.sqlite",
+}
+
+// OpenPlayerStore opens the named backend at path, or at the backend's
+// default file when path is empty. Only the json backend takes options.
+func OpenPlayerStore(backend, path string, options ...FileSystemOption) (PlayerStore, func(), error) {
+	if path == "" {
+		path = DefaultDBFile(backend)
+	}
+
+	if len(options) > 0 && backend != JSONBackend {
+		return nil, nil, fmt.Errorf("the %s store can't be encrypted, only the %s store can", backend, JSONBackend)
+	}
+
+	var store PlayerStore
+	var closeFunc func()
+	var err error
+
+	switch backend {
+	case JSONBackend:
+		store, closeFunc, err = FileSystemPlayerStoreFromFile(path, options...)
+	case EventLogBackend:
+		store, closeFunc, err = EventLogPlayerStoreFromFile(path)
+	case SQLiteBackend:
+		store, closeFunc, err = SQLPlayerStoreFromFile(path)
+	default:
+		err = fmt.Errorf("unknown store %q, want one of %s, %s or %s", backend, JSONBackend, EventLogBackend, SQLiteBackend)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return store, closeFunc, nil
+}
+
+func DefaultDBFile(backend string) string {
+	return defaultDBFiles[backend]
+}
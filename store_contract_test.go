@@ -0,0 +1,70 @@
+package poker_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func TestPlayerStoreContract(t *testing.T) {
+	key := mustGenerateKey(t)
+
+	backends := map[string]func(path string) (poker.PlayerStore, func(), error){
+		"json": func(path string) (poker.PlayerStore, func(), error) {
+			return poker.OpenPlayerStore(poker.JSONBackend, path)
+		},
+		"encrypted json": func(path string) (poker.PlayerStore, func(), error) {
+			return poker.OpenPlayerStore(poker.JSONBackend, path, poker.WithEncryptionKey(key))
+		},
+		"json writing behind": func(path string) (poker.PlayerStore, func(), error) {
+			store, closeStore, err := poker.FileSystemPlayerStoreFromFile(path)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			stop, err := store.WriteBehind(time.Hour, 10, nil)
+			poker.AssertNoError(t, err)
+			return store, func() { stop(); closeStore() }, nil
+		},
+		"eventlog": func(path string) (poker.PlayerStore, func(), error) {
+			return poker.OpenPlayerStore(poker.EventLogBackend, path)
+		},
+		"sqlite": func(path string) (poker.PlayerStore, func(), error) {
+			return poker.OpenPlayerStore(poker.SQLiteBackend, path)
+		},
+	}
+
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			poker.AssertPlayerStoreContract(t, reopenableStore(open))
+		})
+	}
+}
+
+// reopenableStore makes each store in a new directory, closing it when the
+// test ends or it's reopened.
+func reopenableStore(open func(path string) (poker.PlayerStore, func(), error)) poker.PlayerStoreFactory {
+	return func(t *testing.T) (poker.PlayerStore, func() poker.PlayerStore) {
+		t.Helper()
+
+		path := filepath.Join(t.TempDir(), "players")
+		closeStore := func() {}
+
+		reopen := func() poker.PlayerStore {
+			t.Helper()
+			closeStore()
+
+			store, closeFunc, err := open(path)
+			poker.AssertNoError(t, err)
+
+			closeStore = closeFunc
+			return store
+		}
+
+		t.Cleanup(func() { closeStore() })
+		return reopen(), reopen
+	}
+}
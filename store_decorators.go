@@ -0,0 +1,303 @@
+package poker
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Unwrapper is implemented by stores that wrap another store, so the
+// optional features of the store underneath can still be found, see StoreAs.
+type Unwrapper interface {
+	Unwrap() PlayerStore
+}
+
+// StoreAs finds the first store in the chain of wrapped stores starting at
+// store that implements T, the way errors.As finds an error.
+func StoreAs[T any](store PlayerStore) (T, bool) {
+	for store != nil {
+		if found, ok := store.(T); ok {
+			return found, true
+		}
+
+		wrapper, ok := store.(Unwrapper)
+
+		if !ok {
+			break
+		}
+
+		store = wrapper.Unwrap()
+	}
+
+	var none T
+	return none, false
+}
+
+// VersionedStore is implemented by player stores that count the changes made
+// to them, however they were made, so a cache can tell when what it keeps is
+// out of date.
+type VersionedStore interface {
+	Version() uint64
+}
+
+// CachedPlayerStore serves GetLeague from a copy of the last league it read,
+// until a win is recorded through it, the store underneath reports a change
+// as a VersionedStore, or the copy is older than maxAge. Changes made through
+// features found underneath it with StoreAs, such as renaming a player, are
+// only seen through the version, so maxAge bounds how long they're left out
+// of the league of a store that isn't versioned.
+type CachedPlayerStore struct {
+	store     PlayerStore
+	versioned VersionedStore
+	cache     *leagueCache
+}
+
+// leagueCache is shared by a cached store and the views of it From returns.
+// generation moves on whenever the cache is cleared, so a league read from
+// the store before then isn't kept, and version is the version of the store
+// the league was read at.
+type leagueCache struct {
+	mu         sync.Mutex
+	league     League
+	cachedAt   time.Time
+	cached     bool
+	generation int
+	version    uint64
+	maxAge     time.Duration
+}
+
+// NewCachedPlayerStore caches store's league for up to maxAge, or until it
+// changes when maxAge is zero.
+func NewCachedPlayerStore(store PlayerStore, maxAge time.Duration) *CachedPlayerStore {
+	return newCachedPlayerStore(store, &leagueCache{maxAge: maxAge})
+}
+
+func newCachedPlayerStore(store PlayerStore, cache *leagueCache) *CachedPlayerStore {
+	versioned, _ := StoreAs[VersionedStore](store)
+	return &CachedPlayerStore{store: store, versioned: versioned, cache: cache}
+}
+
+func (c *CachedPlayerStore) GetLeague() (League, error) {
+	// the version is read before the league, so a change made while it's
+	// read leaves the league cached at a version that's already out of date
+	version := c.version()
+
+	cache := c.cache
+	cache.mu.Lock()
+
+	if cache.cached && cache.version == version && (cache.maxAge <= 0 || time.Since(cache.cachedAt) < cache.maxAge) {
+		league := slices.Clone(cache.league)
+		cache.mu.Unlock()
+		return league, nil
+	}
+
+	generation := cache.generation
+	cache.mu.Unlock()
+
+	league, err := c.store.GetLeague()
+
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mu.Lock()
+	if cache.generation == generation {
+		cache.league = slices.Clone(league)
+		cache.cachedAt = time.Now()
+		cache.cached = true
+		cache.version = version
+	}
+	cache.mu.Unlock()
+
+	return league, nil
+}
+
+func (c *CachedPlayerStore) GetPlayerScore(name string) (int, error) {
+	return c.store.GetPlayerScore(name)
+}
+
+// RecordWin records the win and then clears the cached league, even when the
+// win fails, as the store may have changed part way.
+func (c *CachedPlayerStore) RecordWin(name string) error {
+	defer c.cache.clear()
+	return c.store.RecordWin(name)
+}
+
+func (c *CachedPlayerStore) From(source Source) PlayerStore {
+	return newCachedPlayerStore(StoreFrom(c.store, source), c.cache)
+}
+
+func (c *CachedPlayerStore) Unwrap() PlayerStore {
+	return c.store
+}
+
+func (c *CachedPlayerStore) version() uint64 {
+	if c.versioned == nil {
+		return 0
+	}
+	return c.versioned.Version()
+}
+
+func (l *leagueCache) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.league = nil
+	l.cached = false
+	l.generation++
+}
+
+// MetricsStore is implemented by player stores that measure the calls made to
+// them.
+type MetricsStore interface {
+	GetMetrics() map[string]MethodMetrics
+}
+
+// MethodMetrics are the calls made to one method of a store, how many of them
+// failed, and how long they took in total and at most.
+type MethodMetrics struct {
+	Calls     int
+	Errors    int
+	TotalTime time.Duration
+	MaxTime   time.Duration
+}
+
+// MeanTime is how long a call took on average.
+func (m MethodMetrics) MeanTime() time.Duration {
+	if m.Calls == 0 {
+		return 0
+	}
+	return m.TotalTime / time.Duration(m.Calls)
+}
+
+// MetricsPlayerStore counts the calls made to each PlayerStore method of the
+// store it wraps and times them. Calls to features found with StoreAs aren't
+// counted.
+type MetricsPlayerStore struct {
+	store   PlayerStore
+	metrics *storeMetrics
+}
+
+// storeMetrics are shared by a metrics store and the views of it From
+// returns.
+type storeMetrics struct {
+	mu      sync.Mutex
+	methods map[string]MethodMetrics
+}
+
+func NewMetricsPlayerStore(store PlayerStore) *MetricsPlayerStore {
+	return &MetricsPlayerStore{store: store, metrics: &storeMetrics{methods: map[string]MethodMetrics{}}}
+}
+
+func (m *MetricsPlayerStore) GetLeague() (League, error) {
+	start := time.Now()
+	league, err := m.store.GetLeague()
+	m.metrics.observe("GetLeague", start, err)
+	return league, err
+}
+
+func (m *MetricsPlayerStore) GetPlayerScore(name string) (int, error) {
+	start := time.Now()
+	score, err := m.store.GetPlayerScore(name)
+	m.metrics.observe("GetPlayerScore", start, err)
+	return score, err
+}
+
+func (m *MetricsPlayerStore) RecordWin(name string) error {
+	start := time.Now()
+	err := m.store.RecordWin(name)
+	m.metrics.observe("RecordWin", start, err)
+	return err
+}
+
+// GetMetrics returns the metrics of each method called so far.
+func (m *MetricsPlayerStore) GetMetrics() map[string]MethodMetrics {
+	m.metrics.mu.Lock()
+	defer m.metrics.mu.Unlock()
+
+	return maps.Clone(m.metrics.methods)
+}
+
+func (m *MetricsPlayerStore) From(source Source) PlayerStore {
+	return &MetricsPlayerStore{store: StoreFrom(m.store, source), metrics: m.metrics}
+}
+
+func (m *MetricsPlayerStore) Unwrap() PlayerStore {
+	return m.store
+}
+
+func (s *storeMetrics) observe(method string, start time.Time, err error) {
+	took := time.Since(start)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metrics := s.methods[method]
+	metrics.Calls++
+	metrics.TotalTime += took
+	metrics.MaxTime = max(metrics.MaxTime, took)
+
+	if err != nil {
+		metrics.Errors++
+	}
+
+	s.methods[method] = metrics
+}
+
+// LoggingPlayerStore logs each call made to the store it wraps as a
+// structured record with the method, player, duration and any error. Wins
+// are logged at info level, reads at debug level and failures at error level.
+// Calls to features found with StoreAs aren't logged.
+type LoggingPlayerStore struct {
+	store  PlayerStore
+	logger *slog.Logger
+}
+
+func NewLoggingPlayerStore(store PlayerStore, logger *slog.Logger) *LoggingPlayerStore {
+	return &LoggingPlayerStore{store: store, logger: logger}
+}
+
+func (l *LoggingPlayerStore) GetLeague() (League, error) {
+	start := time.Now()
+	league, err := l.store.GetLeague()
+	l.log(slog.LevelDebug, "GetLeague", start, err, slog.Int("players", len(league)))
+	return league, err
+}
+
+func (l *LoggingPlayerStore) GetPlayerScore(name string) (int, error) {
+	start := time.Now()
+	score, err := l.store.GetPlayerScore(name)
+	l.log(slog.LevelDebug, "GetPlayerScore", start, err, slog.String("player", name), slog.Int("score", score))
+	return score, err
+}
+
+func (l *LoggingPlayerStore) RecordWin(name string) error {
+	start := time.Now()
+	err := l.store.RecordWin(name)
+	l.log(slog.LevelInfo, "RecordWin", start, err, slog.String("player", name))
+	return err
+}
+
+// From logs the calls made through the view it returns with source.
+func (l *LoggingPlayerStore) From(source Source) PlayerStore {
+	logger := l.logger.With(slog.String("source", source.Kind), slog.String("remote", source.Remote))
+	return &LoggingPlayerStore{store: StoreFrom(l.store, source), logger: logger}
+}
+
+func (l *LoggingPlayerStore) Unwrap() PlayerStore {
+	return l.store
+}
+
+func (l *LoggingPlayerStore) log(level slog.Level, method string, start time.Time, err error, attrs ...slog.Attr) {
+	attrs = append(attrs, slog.String("method", method), slog.Duration("duration", time.Since(start)))
+
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	l.logger.LogAttrs(context.Background(), level, "player store call", attrs...)
+}
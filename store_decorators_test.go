@@ -0,0 +1,319 @@
+package poker_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func TestStoreDecoratorsMeetTheContract(t *testing.T) {
+	decorators := map[string]func(poker.PlayerStore) poker.PlayerStore{
+		"cached": func(store poker.PlayerStore) poker.PlayerStore {
+			return poker.NewCachedPlayerStore(store, 0)
+		},
+		"measured": func(store poker.PlayerStore) poker.PlayerStore {
+			return poker.NewMetricsPlayerStore(store)
+		},
+		"logged": func(store poker.PlayerStore) poker.PlayerStore {
+			return poker.NewLoggingPlayerStore(store, discardLogger())
+		},
+		"stacked": stackDecorators,
+	}
+
+	for name, decorate := range decorators {
+		t.Run(name, func(t *testing.T) {
+			poker.AssertPlayerStoreContract(t, reopenableStore(func(path string) (poker.PlayerStore, func(), error) {
+				store, closeStore, err := poker.OpenPlayerStore(poker.JSONBackend, path)
+
+				if err != nil {
+					return nil, nil, err
+				}
+
+				return decorate(store), closeStore, nil
+			}))
+		})
+	}
+}
+
+func TestCachedPlayerStore(t *testing.T) {
+	t.Run("serves the league it read until a win is recorded through it", func(t *testing.T) {
+		store := &poker.StubPlayerStore{League: []poker.Player{{"Chris", 33}, {"Cleo", 10}}}
+		cached := poker.NewCachedPlayerStore(store, 0)
+		league := poker.MustGetLeague(t, cached)
+
+		store.League = []poker.Player{{"Chris", 33}, {"Cleo", 11}}
+		poker.AssertLeague(t, poker.MustGetLeague(t, cached), league)
+
+		poker.MustRecordWin(t, cached, "Cleo")
+		poker.AssertLeague(t, poker.MustGetLeague(t, cached), store.League)
+	})
+
+	t.Run("reads the league again once it's older than the max age", func(t *testing.T) {
+		store := &poker.StubPlayerStore{League: []poker.Player{{"Cleo", 10}}}
+		cached := poker.NewCachedPlayerStore(store, watchInterval)
+		poker.MustGetLeague(t, cached)
+
+		store.League = []poker.Player{{"Cleo", 11}}
+
+		eventually(t, func() bool {
+			return poker.MustGetLeague(t, cached).Find("Cleo").Wins == 11
+		})
+	})
+
+	t.Run("reads the league again once a versioned store changes", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+		cached := poker.NewCachedPlayerStore(store, 0)
+		poker.MustGetLeague(t, cached)
+
+		poker.MustRecordWin(t, store, "Cleo")
+		poker.AssertLeague(t, poker.MustGetLeague(t, cached), []poker.Player{{"Chris", 33}, {"Cleo", 11}})
+	})
+
+	t.Run("reads the league again after a change made underneath it", func(t *testing.T) {
+		cached := poker.NewCachedPlayerStore(mustMakeAliasStore(t), 0)
+		poker.MustGetLeague(t, cached)
+
+		admin, ok := poker.StoreAs[poker.PlayerAdminStore](cached)
+
+		if !ok {
+			t.Fatal("expected to find the store's admin features underneath the cache")
+		}
+
+		poker.AssertNoError(t, admin.RenamePlayer("Cleo", "Cleopatra", "full name"))
+		poker.AssertLeague(t, poker.MustGetLeague(t, cached), []poker.Player{{"Chris", 33}, {"Cleopatra", 10}})
+	})
+
+	t.Run("keeps the league when features underneath are only read", func(t *testing.T) {
+		measured := poker.NewMetricsPlayerStore(mustMakeAliasStore(t))
+		cached := poker.NewCachedPlayerStore(measured, 0)
+		poker.MustGetLeague(t, cached)
+
+		audit, _ := poker.StoreAs[poker.AuditStore](cached)
+		_, err := audit.GetAuditLog()
+		poker.AssertNoError(t, err)
+
+		poker.MustGetLeague(t, cached)
+
+		if got := measured.GetMetrics()["GetLeague"].Calls; got != 1 {
+			t.Errorf("got %d GetLeague calls underneath the cache want 1", got)
+		}
+	})
+
+	t.Run("doesn't share the league it keeps with callers", func(t *testing.T) {
+		cached := poker.NewCachedPlayerStore(mustMakeAliasStore(t), 0)
+
+		poker.MustGetLeague(t, cached)[0].Wins = 0
+
+		poker.AssertLeague(t, poker.MustGetLeague(t, cached), []poker.Player{{"Chris", 33}, {"Cleo", 10}})
+	})
+}
+
+func TestMetricsPlayerStore(t *testing.T) {
+	t.Run("counts and times the calls to each method", func(t *testing.T) {
+		measured := poker.NewMetricsPlayerStore(mustMakeAliasStore(t))
+
+		poker.MustRecordWin(t, measured, "Chris")
+		poker.MustRecordWin(t, poker.StoreFrom(measured, poker.CLISource), "Chris")
+		poker.MustGetLeague(t, measured)
+
+		metrics := measured.GetMetrics()
+
+		if got := metrics["RecordWin"]; got.Calls != 2 || got.Errors != 0 || got.TotalTime <= 0 || got.MaxTime > got.TotalTime {
+			t.Errorf("got RecordWin metrics %+v want 2 timed calls", got)
+		}
+
+		if got := metrics["GetLeague"].Calls; got != 1 {
+			t.Errorf("got %d GetLeague calls want 1", got)
+		}
+
+		if _, ok := metrics["GetPlayerScore"]; ok {
+			t.Errorf("got metrics for GetPlayerScore, which wasn't called")
+		}
+	})
+
+	t.Run("counts the calls that fail", func(t *testing.T) {
+		measured := poker.NewMetricsPlayerStore(&poker.StubPlayerStore{Err: errors.New("disk full")})
+
+		measured.RecordWin("Chris")
+
+		if got := measured.GetMetrics()["RecordWin"]; got.Calls != 1 || got.Errors != 1 {
+			t.Errorf("got RecordWin metrics %+v want 1 failed call", got)
+		}
+	})
+}
+
+func TestLoggingPlayerStore(t *testing.T) {
+	t.Run("logs each call with its method, player and source", func(t *testing.T) {
+		var logs bytes.Buffer
+		logged := poker.NewLoggingPlayerStore(mustMakeAliasStore(t), jsonLogger(&logs, slog.LevelDebug))
+
+		poker.MustRecordWin(t, poker.StoreFrom(logged, poker.HTTPSource("10.0.0.1:1234")), "Chris")
+		poker.AssertScore(t, logged, "Chris", 34)
+
+		records := decodeLogs(t, &logs)
+
+		if len(records) != 2 {
+			t.Fatalf("got %d log records want 2, %v", len(records), records)
+		}
+
+		assertLogRecord(t, records[0], map[string]any{"level": "INFO", "method": "RecordWin", "player": "Chris", "source": "http", "remote": "10.0.0.1:1234"})
+		assertLogRecord(t, records[1], map[string]any{"level": "DEBUG", "method": "GetPlayerScore", "player": "Chris", "score": float64(34)})
+	})
+
+	t.Run("logs failures as errors", func(t *testing.T) {
+		var logs bytes.Buffer
+		logged := poker.NewLoggingPlayerStore(&poker.StubPlayerStore{Err: errors.New("disk full")}, jsonLogger(&logs, slog.LevelError))
+
+		logged.GetLeague()
+
+		records := decodeLogs(t, &logs)
+
+		if len(records) != 1 {
+			t.Fatalf("got %d log records want 1, %v", len(records), records)
+		}
+
+		assertLogRecord(t, records[0], map[string]any{"level": "ERROR", "method": "GetLeague", "error": "disk full"})
+	})
+}
+
+func TestStackedStoreDecorators(t *testing.T) {
+	t.Run("finds the features of the store underneath", func(t *testing.T) {
+		stacked := stackDecorators(mustMakeAliasStore(t))
+
+		if _, ok := poker.StoreAs[poker.SnapshotStore](stacked); !ok {
+			t.Error("expected to find the snapshot store underneath")
+		}
+
+		if _, ok := poker.StoreAs[poker.MetricsStore](stacked); !ok {
+			t.Error("expected to find the metrics store in the stack")
+		}
+
+		if _, ok := poker.StoreAs[poker.SnapshotStore](poker.NewCachedPlayerStore(&poker.StubPlayerStore{}, 0)); ok {
+			t.Error("didn't expect a snapshot store underneath a stub")
+		}
+	})
+
+	t.Run("logs changes made through the stack against their source", func(t *testing.T) {
+		store := mustMakeAliasStore(t)
+
+		poker.MustRecordWin(t, poker.StoreFrom(stackDecorators(store), poker.CLISource), "Chris")
+
+		if entry := lastAuditEntry(t, store); entry.Source != poker.CLISource {
+			t.Errorf("got audit entry %+v want it from the cli", entry)
+		}
+	})
+
+	t.Run("serves the optional endpoints through the stack", func(t *testing.T) {
+		server, _ := poker.NewPlayerServer(stackDecorators(mustMakeAliasStore(t)), &poker.StubGameStore{}, dummyGame)
+		server.AdminToken = adminToken
+
+		server.ServeHTTP(httptest.NewRecorder(), poker.NewGetLeagueRequest())
+
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewRenamePlayerRequest("Cleo", "Cleopatra", "full name", adminToken))
+		assertStatus(t, response, http.StatusAccepted)
+
+		response = httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetLeagueRequest())
+
+		got := poker.GetLeagueFromResponse(t, response.Body)
+		poker.AssertLeague(t, got, []poker.Player{{"Chris", 33}, {"Cleopatra", 10}})
+	})
+}
+
+func TestGETAdminMetrics(t *testing.T) {
+	t.Run("returns the player store's metrics", func(t *testing.T) {
+		server, _ := poker.NewPlayerServer(stackDecorators(mustMakeAliasStore(t)), &poker.StubGameStore{}, dummyGame)
+		server.AdminToken = adminToken
+
+		server.ServeHTTP(httptest.NewRecorder(), poker.NewPostWinRequest("Chris"))
+
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetMetricsRequest(adminToken))
+
+		var got map[string]poker.MethodMetrics
+		decodeJSON(t, response, &got)
+
+		if got["RecordWin"].Calls != 1 {
+			t.Errorf("got metrics %+v want 1 RecordWin call", got)
+		}
+	})
+
+	t.Run("returns 501 when the store isn't measured", func(t *testing.T) {
+		server, _ := poker.NewPlayerServer(mustMakeAliasStore(t), &poker.StubGameStore{}, dummyGame)
+		server.AdminToken = adminToken
+
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetMetricsRequest(adminToken))
+
+		assertStatus(t, response, http.StatusNotImplemented)
+	})
+
+	t.Run("needs the admin token", func(t *testing.T) {
+		server, _ := poker.NewPlayerServer(stackDecorators(mustMakeAliasStore(t)), &poker.StubGameStore{}, dummyGame)
+		server.AdminToken = adminToken
+
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetMetricsRequest(""))
+
+		assertStatus(t, response, http.StatusUnauthorized)
+	})
+}
+
+// stackDecorators wraps store the way the webserver does with every
+// decorator turned on.
+func stackDecorators(store poker.PlayerStore) poker.PlayerStore {
+	cached := poker.NewCachedPlayerStore(store, time.Hour)
+	measured := poker.NewMetricsPlayerStore(cached)
+	return poker.NewLoggingPlayerStore(measured, discardLogger())
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+func jsonLogger(logs *bytes.Buffer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: level}))
+}
+
+func decodeLogs(t *testing.T, logs *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("could not parse log record %q, %v", line, err)
+		}
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func assertLogRecord(t *testing.T, record, want map[string]any) {
+	t.Helper()
+
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("got %s %v in log record %v, want %v", key, record[key], record, value)
+		}
+	}
+
+	if _, ok := record["duration"]; !ok {
+		t.Errorf("expected log record %v to have a duration", record)
+	}
+}
@@ -1,13 +1,70 @@
 package poker
 
-import "os"
+import (
+	"fmt"
+	"os"
+)
 
+const backupSuffix = ".bak"
+
+// Tape replaces the contents of File on every Write. The new contents are
+// written to a temp file, synced and renamed over the original, and the
+// previous contents are kept alongside as the last good copy.
 type Tape struct {
 	File *os.File
+
+	// rejected is set while File holds contents that failed to load, which
+	// are written over without replacing the last good copy
+	rejected bool
 }
 
 func (t *Tape) Write(p []byte) (n int, err error) {
-	t.File.Truncate(0)
-	t.File.Seek(0, 0)
-	return t.File.Write(p)
+	path := t.File.Name()
+
+	if !t.rejected {
+		if err := keepBackup(path); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := writeFileAtomically(path, p); err != nil {
+		return 0, err
+	}
+
+	t.rejected = false
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0666)
+
+	if err != nil {
+		return 0, fmt.Errorf("problem reopening %s, %v", path, err)
+	}
+
+	t.File.Close()
+	t.File = file
+
+	return len(p), nil
+}
+
+func keepBackup(path string) error {
+	backup := path + backupSuffix
+	tmp := backup + ".tmp"
+
+	os.Remove(tmp)
+
+	if err := os.Link(path, tmp); err != nil {
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			return fmt.Errorf("problem reading %s for backup, %v", path, err)
+		}
+
+		return writeFileAtomically(backup, data)
+	}
+
+	if err := os.Rename(tmp, backup); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("problem replacing backup %s, %v", backup, err)
+	}
+
+	return nil
 }
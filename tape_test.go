@@ -8,20 +8,39 @@ import (
 )
 
 func TestTape_Write(t *testing.T) {
-	file, clean := createTempFile(t, "12345")
-	defer clean()
+	t.Run("replaces the file contents", func(t *testing.T) {
+		file, clean := createTempFile(t, "12345")
+		defer clean()
 
-	tape := &poker.Tape{File: file}
+		tape := &poker.Tape{File: file}
 
-	tape.Write([]byte("abc"))
+		tape.Write([]byte("abc"))
 
-	file.Seek(0, 0)
-	newFileContents, _ := ioutil.ReadAll(file)
+		tape.File.Seek(0, 0)
+		newFileContents, _ := ioutil.ReadAll(tape.File)
 
-	got := string(newFileContents)
-	want := "abc"
+		got := string(newFileContents)
+		want := "abc"
 
-	if got != want {
-		t.Errorf("got %q want %q", got, want)
-	}
+		if got != want {
+			t.Errorf("got %q want %q", got, want)
+		}
+	})
+
+	t.Run("keeps the previous contents as a backup", func(t *testing.T) {
+		file, clean := createTempFile(t, "12345")
+		defer clean()
+
+		tape := &poker.Tape{File: file}
+
+		_, err := tape.Write([]byte("abc"))
+		poker.AssertNoError(t, err)
+
+		got := readFile(t, file.Name()+".bak")
+		want := "12345"
+
+		if got != want {
+			t.Errorf("got backup %q want %q", got, want)
+		}
+	})
 }
@@ -1,52 +1,140 @@
 package poker
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 )
 
 type GameSpy struct {
-	StartCalled bool
-	StartedWith int
-	BlindAlert  []byte
+	mu sync.Mutex
+
+	StartCalled      bool
+	StartedWith      int
+	StartedWithNames []string
+	StartedID        int
+	StartError       error
+	BlindAlert       []byte
 
 	FinishCalled bool
 	FinishedWith string
+	FinishedFrom Source
+	FinishError  error
 }
 
-func (g *GameSpy) Start(numberOfPlayers int, alertsDestination io.Writer) {
+func (g *GameSpy) Start(numberOfPlayers int, players []string, alertsDestination io.Writer) (GameInProgress, error) {
+	g.mu.Lock()
 	g.StartedWith = numberOfPlayers
+	g.StartedWithNames = players
 	g.StartCalled = true
+	g.mu.Unlock()
+
+	if g.StartError != nil {
+		return nil, g.StartError
+	}
+
 	alertsDestination.Write(g.BlindAlert)
+	return g, nil
+}
+
+func (g *GameSpy) ID() int {
+	return g.StartedID
 }
 
-func (g *GameSpy) Finish(winner string) {
+func (g *GameSpy) Finish(winner string, source Source) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	g.FinishedWith = winner
+	g.FinishedFrom = source
 	g.FinishCalled = true
+	return g.FinishError
+}
+
+func (g *GameSpy) StartedWithPlayers() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.StartedWith
+}
+
+func (g *GameSpy) StartedWithPlayerNames() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.StartedWithNames
+}
+
+func (g *GameSpy) FinishedWithWinner() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.FinishedWith
+}
+
+func (g *GameSpy) FinishedFromSource() Source {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.FinishedFrom
 }
 
 type StubPlayerStore struct {
 	Scores   map[string]int
 	WinCalls []string
 	League   []Player
+	Err      error
 }
 
-func (s *StubPlayerStore) GetPlayerScore(name string) int {
+func (s *StubPlayerStore) GetPlayerScore(name string) (int, error) {
 	score := s.Scores[name]
-	return score
+	return score, s.Err
 }
 
-func (s *StubPlayerStore) GetLeague() League {
-	return s.League
+func (s *StubPlayerStore) GetLeague() (League, error) {
+	return s.League, s.Err
 }
 
-func (s *StubPlayerStore) RecordWin(name string) {
+func (s *StubPlayerStore) RecordWin(name string) error {
+	if s.Err != nil {
+		return s.Err
+	}
 	s.WinCalls = append(s.WinCalls, name)
+	return nil
+}
+
+type StubGameStore struct {
+	Games []GameRecord
+	Err   error
+}
+
+func (s *StubGameStore) RecordGame(game GameRecord) (GameRecord, error) {
+	if s.Err != nil {
+		return GameRecord{}, s.Err
+	}
+	game.ID = len(s.Games) + 1
+	s.Games = append(s.Games, game)
+	return game, nil
+}
+
+func (s *StubGameStore) GetGames() ([]GameRecord, error) {
+	return s.Games, s.Err
+}
+
+func (s *StubGameStore) GetGame(id int) (GameRecord, error) {
+	if s.Err != nil {
+		return GameRecord{}, s.Err
+	}
+	for _, game := range s.Games {
+		if game.ID == id {
+			return game, nil
+		}
+	}
+	return GameRecord{}, ErrGameNotFound
 }
 
 type SpyBlindAlerter struct {
@@ -71,11 +159,27 @@ func NewGetScoreRequest(name string) *http.Request {
 	return request
 }
 
+func NewGetStatsRequest(name string) *http.Request {
+	request := NewGetScoreRequest(name)
+	request.Header.Set("Accept", "application/json")
+	return request
+}
+
+func NewGetRatingHistoryRequest(name string) *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/players/%s/ratings", name), nil)
+	return request
+}
+
 func NewGetLeagueRequest() *http.Request {
 	request, _ := http.NewRequest(http.MethodGet, "/league", nil)
 	return request
 }
 
+func NewGetLeagueQueryRequest(query string) *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, "/league?"+query, nil)
+	return request
+}
+
 func NewPostWinRequest(name string) *http.Request {
 	request, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/players/%s", name), nil)
 	return request
@@ -86,6 +190,154 @@ func NewGameRequest() *http.Request {
 	return request
 }
 
+func NewGetSeasonsRequest() *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, "/leagues", nil)
+	return request
+}
+
+func NewGetSeasonLeagueRequest(season string) *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/leagues/%s", season), nil)
+	return request
+}
+
+func NewGetSeasonScoreRequest(season, name string) *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/leagues/%s/players/%s", season, name), nil)
+	return request
+}
+
+func NewGetAliasesRequest(name string) *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/players/%s/aliases", name), nil)
+	return request
+}
+
+func NewPostAliasRequest(name, alias, token string) *http.Request {
+	return newAdminRequest(http.MethodPost, fmt.Sprintf("/players/%s/aliases/%s", name, alias), token)
+}
+
+func NewPostMergeRequest(from, into, token string) *http.Request {
+	return newAdminRequest(http.MethodPost, fmt.Sprintf("/players/%s/merge-into/%s", from, into), token)
+}
+
+func NewRenamePlayerRequest(name, newName, reason, token string) *http.Request {
+	return newCorrectionRequest(http.MethodPost, fmt.Sprintf("/players/%s/rename/%s", name, newName), reason, token)
+}
+
+func NewDeletePlayerRequest(name, reason, token string) *http.Request {
+	return newCorrectionRequest(http.MethodDelete, fmt.Sprintf("/players/%s", name), reason, token)
+}
+
+func NewSetScoreRequest(name string, wins int, reason, token string) *http.Request {
+	return newCorrectionRequest(http.MethodPut, fmt.Sprintf("/players/%s/score/%d", name, wins), reason, token)
+}
+
+func NewAdjustScoreRequest(name string, delta int, reason, token string) *http.Request {
+	return newCorrectionRequest(http.MethodPost, fmt.Sprintf("/players/%s/score/%d", name, delta), reason, token)
+}
+
+func NewGetPlayerChangesRequest(name string) *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/players/%s/changes", name), nil)
+	return request
+}
+
+func newCorrectionRequest(method, path, reason, token string) *http.Request {
+	return newAdminRequest(method, path+"?reason="+url.QueryEscape(reason), token)
+}
+
+func NewGetAuditRequest(query string) *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, "/audit?"+query, nil)
+	return request
+}
+
+func NewUndoRequest(id int, token string) *http.Request {
+	return newAdminRequest(http.MethodPost, fmt.Sprintf("/audit/%d/undo", id), token)
+}
+
+func NewGetSnapshotsRequest(token string) *http.Request {
+	return newAdminRequest(http.MethodGet, "/admin/snapshots", token)
+}
+
+func NewTakeSnapshotRequest(token string) *http.Request {
+	return newAdminRequest(http.MethodPost, "/admin/snapshots", token)
+}
+
+func NewRestoreRequest(name, token string) *http.Request {
+	return newAdminRequest(http.MethodPost, fmt.Sprintf("/admin/snapshots/%s/restore", name), token)
+}
+
+// NewImportRequest uploads data as filename to the import endpoint, along
+// with form fields such as conflicts and dry-run.
+func NewImportRequest(filename, data string, fields map[string]string, token string) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	for name, value := range fields {
+		form.WriteField(name, value)
+	}
+
+	file, _ := form.CreateFormFile("file", filename)
+	io.WriteString(file, data)
+	form.Close()
+
+	request := newAdminRequest(http.MethodPost, "/admin/import", token)
+	request.Body = io.NopCloser(&body)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	return request
+}
+
+func NewGetLeagueCSVRequest() *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, "/league.csv", nil)
+	return request
+}
+
+func NewGetGamesCSVRequest() *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, "/games.csv", nil)
+	return request
+}
+
+func NewGetMetricsRequest(token string) *http.Request {
+	return newAdminRequest(http.MethodGet, "/admin/metrics", token)
+}
+
+func newAdminRequest(method, path, token string) *http.Request {
+	request, _ := http.NewRequest(method, path, nil)
+
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	return request
+}
+
+func NewPostLedgerEntryRequest(entry LedgerEntry) *http.Request {
+	body, _ := json.Marshal(entry)
+	request, _ := http.NewRequest(http.MethodPost, "/ledger", bytes.NewReader(body))
+	return request
+}
+
+func NewGetLedgerRequest(query string) *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, "/ledger?"+query, nil)
+	return request
+}
+
+func NewGetBalancesRequest() *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, "/balances", nil)
+	return request
+}
+
+func NewSettleUpRequest() *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, "/settle-up", nil)
+	return request
+}
+
+func NewGetGamesRequest() *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, "/games", nil)
+	return request
+}
+
+func NewGetGameRequest(id string) *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/games/%s", id), nil)
+	return request
+}
+
 func AssertResponseBody(t testing.TB, got, want string) {
 	t.Helper()
 	if got != want {
@@ -102,7 +354,7 @@ func AssertLeague(t testing.TB, got, want []Player) {
 
 func AssertContentType(t testing.TB, response *httptest.ResponseRecorder, want string) {
 	t.Helper()
-	if response.Result().Header.Get("content-type") != "application/json" {
+	if response.Result().Header.Get("content-type") != want {
 		t.Errorf("response did not have content-type of %s, got %v", want, response.Result().Header)
 	}
 }
@@ -123,6 +375,40 @@ func AssertNoError(t *testing.T, err error) {
 	}
 }
 
+func AssertScore(t testing.TB, store PlayerStore, name string, want int) {
+	t.Helper()
+
+	got, err := store.GetPlayerScore(name)
+
+	if err != nil {
+		t.Fatalf("didn't expect an error getting the score for %s but got: %v", name, err)
+	}
+
+	if got != want {
+		t.Errorf("got score %d for %s, want %d", got, name, want)
+	}
+}
+
+func MustRecordWin(t testing.TB, store PlayerStore, name string) {
+	t.Helper()
+
+	if err := store.RecordWin(name); err != nil {
+		t.Fatalf("didn't expect an error recording a win for %s but got: %v", name, err)
+	}
+}
+
+func MustGetLeague(t testing.TB, store PlayerStore) League {
+	t.Helper()
+
+	league, err := store.GetLeague()
+
+	if err != nil {
+		t.Fatalf("didn't expect an error getting the league but got: %v", err)
+	}
+
+	return league
+}
+
 func AssertPlayerWin(t testing.TB, store *StubPlayerStore, winner string) {
 	t.Helper()
 
@@ -135,3 +421,142 @@ func AssertPlayerWin(t testing.TB, store *StubPlayerStore, winner string) {
 	}
 
 }
+
+// PlayerStoreFactory makes a new, empty store for each check of the player
+// store contract. Calling reopen closes the store and opens the same data
+// again, as a restarted server would.
+type PlayerStoreFactory func(t *testing.T) (store PlayerStore, reopen func() PlayerStore)
+
+// AssertPlayerStoreContract checks that the stores newStore makes behave like
+// FileSystemPlayerStore: looking up scores, recording wins, ordering the
+// league, keeping both across reopening, and recording and reading from many
+// goroutines at once. Run it under the race detector to catch unsafe access.
+func AssertPlayerStoreContract(t *testing.T, newStore PlayerStoreFactory) {
+	t.Run("scores a player who has never won as zero", func(t *testing.T) {
+		store, _ := newStore(t)
+
+		AssertScore(t, store, "Nobody", 0)
+	})
+
+	t.Run("starts with an empty league", func(t *testing.T) {
+		store, _ := newStore(t)
+
+		if league := MustGetLeague(t, store); len(league) != 0 {
+			t.Errorf("got league %v, want it empty", league)
+		}
+	})
+
+	t.Run("records wins against each player", func(t *testing.T) {
+		store, _ := newStore(t)
+
+		MustRecordWin(t, store, "Chris")
+		MustRecordWin(t, store, "Chris")
+		MustRecordWin(t, store, "Cleo")
+
+		AssertScore(t, store, "Chris", 2)
+		AssertScore(t, store, "Cleo", 1)
+	})
+
+	t.Run("treats names the same however they're cased or spaced", func(t *testing.T) {
+		store, _ := newStore(t)
+
+		MustRecordWin(t, store, "Chris")
+		MustRecordWin(t, store, " chris ")
+
+		AssertScore(t, store, "CHRIS", 2)
+		AssertLeague(t, MustGetLeague(t, store), []Player{{"Chris", 2}})
+	})
+
+	t.Run("orders the league by wins then name", func(t *testing.T) {
+		store, _ := newStore(t)
+
+		for _, winner := range []string{"Ruth", "Cleo", "Chris", "Chris", "Cleo", "Chris", "Alex"} {
+			MustRecordWin(t, store, winner)
+		}
+
+		want := []Player{{"Chris", 3}, {"Cleo", 2}, {"Alex", 1}, {"Ruth", 1}}
+		AssertLeague(t, MustGetLeague(t, store), want)
+	})
+
+	t.Run("keeps wins and the league across reopening", func(t *testing.T) {
+		store, reopen := newStore(t)
+
+		MustRecordWin(t, store, "Cleo")
+		MustRecordWin(t, store, "Chris")
+		MustRecordWin(t, store, "Chris")
+
+		reopened := reopen()
+
+		AssertScore(t, reopened, "Chris", 2)
+		AssertLeague(t, MustGetLeague(t, reopened), []Player{{"Chris", 2}, {"Cleo", 1}})
+
+		MustRecordWin(t, reopened, "Cleo")
+		AssertScore(t, reopen(), "Cleo", 2)
+	})
+
+	t.Run("records every win made at once", func(t *testing.T) {
+		store, reopen := newStore(t)
+		players := []string{"Chris", "Cleo", "Ruth"}
+		winsEach := 20
+
+		assertConcurrently(t, len(players)*winsEach, func(i int) error {
+			return store.RecordWin(players[i%len(players)])
+		})
+
+		for _, player := range players {
+			AssertScore(t, store, player, winsEach)
+		}
+
+		reopened := reopen()
+
+		for _, player := range players {
+			AssertScore(t, reopened, player, winsEach)
+		}
+	})
+
+	t.Run("serves reads while wins are recorded", func(t *testing.T) {
+		store, _ := newStore(t)
+		wins := 30
+
+		assertConcurrently(t, wins*3, func(i int) error {
+			switch i % 3 {
+			case 0:
+				return store.RecordWin("Chris")
+			case 1:
+				_, err := store.GetPlayerScore("Chris")
+				return err
+			default:
+				_, err := store.GetLeague()
+				return err
+			}
+		})
+
+		AssertScore(t, store, "Chris", wins)
+	})
+}
+
+// assertConcurrently runs do n times at once, failing the test with the
+// first error any of them returns.
+func assertConcurrently(t *testing.T, n int, do func(i int) error) {
+	t.Helper()
+
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- do(i)
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("didn't expect an error from concurrent calls but got: %v", err)
+		}
+	}
+}
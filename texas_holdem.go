@@ -1,33 +1,134 @@
 package poker
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"time"
 )
 
+var ErrUnknownWinner = errors.New("the winner didn't play in the game")
+
+var blinds = []int{100, 200, 300, 400, 500, 600, 800, 1000, 2000, 4000, 8000}
+
 type TexasHoldem struct {
 	alerter BlindAlerter
 	store   PlayerStore
+	games   GameStore
+}
+
+// texasHoldemGame is one game started by TexasHoldem.
+type texasHoldemGame struct {
+	*TexasHoldem
+
+	id             int
+	startedAt      time.Time
+	players        int
+	names          []string
+	blindIncrement time.Duration
 }
 
-func NewTexasHoldem(alerter BlindAlerter, store PlayerStore) Game {
+func NewTexasHoldem(alerter BlindAlerter, store PlayerStore, games GameStore) Game {
 	return &TexasHoldem{
 		alerter: alerter,
 		store:   store,
+		games:   games,
 	}
 }
 
-func (p *TexasHoldem) Start(numberOfPlayers int, alertsDestination io.Writer) {
+func (p *TexasHoldem) Start(numberOfPlayers int, players []string, alertsDestination io.Writer) (GameInProgress, error) {
+	id := 0
+
+	if opener, ok := p.games.(GameOpener); ok {
+		var err error
+
+		if id, err = opener.OpenGame(); err != nil {
+			return nil, fmt.Errorf("problem starting game, %v", err)
+		}
+	}
+
 	blindIncrement := time.Duration(5+numberOfPlayers) * time.Minute
 
-	blinds := []int{100, 200, 300, 400, 500, 600, 800, 1000, 2000, 4000, 8000}
+	game := &texasHoldemGame{
+		TexasHoldem:    p,
+		id:             id,
+		startedAt:      time.Now(),
+		players:        numberOfPlayers,
+		names:          players,
+		blindIncrement: blindIncrement,
+	}
+
 	blindTime := 0 * time.Second
 	for _, blind := range blinds {
 		p.alerter.ScheduleAlertAt(blindTime, blind, alertsDestination)
 		blindTime = blindTime + blindIncrement
 	}
+
+	return game, nil
+}
+
+func (g *texasHoldemGame) ID() int {
+	return g.id
+}
+
+// Finish records the win, logged as made by source in stores that keep an
+// audit log, and the finished game. When the players were named at the start
+// the winner must be one of them.
+func (g *texasHoldemGame) Finish(winner string, source Source) error {
+	winner = NormalisePlayerName(winner)
+
+	if !g.played(winner) {
+		return fmt.Errorf("%w: %s", ErrUnknownWinner, winner)
+	}
+
+	if err := StoreFrom(g.store, source).RecordWin(winner); err != nil {
+		return err
+	}
+
+	finishedAt := time.Now()
+	record := GameRecord{
+		ID:              g.id,
+		StartedAt:       g.startedAt,
+		FinishedAt:      finishedAt,
+		NumberOfPlayers: g.players,
+		Players:         g.names,
+		Winner:          winner,
+		HighestBlind:    blindReached(finishedAt.Sub(g.startedAt), g.blindIncrement),
+	}
+
+	if _, err := g.games.RecordGame(record); err != nil {
+		return fmt.Errorf("win recorded but game history was not, %v", err)
+	}
+
+	return nil
+}
+
+// played reports whether name was one of the players, which any name is
+// when only the number of players is known.
+func (g *texasHoldemGame) played(name string) bool {
+	if len(g.names) == 0 {
+		return true
+	}
+
+	for _, player := range g.names {
+		if playerKey(player) == playerKey(name) {
+			return true
+		}
+	}
+
+	return false
 }
 
-func (p *TexasHoldem) Finish(winner string) {
-	p.store.RecordWin(winner)
+func blindReached(elapsed, blindIncrement time.Duration) int {
+	if blindIncrement <= 0 {
+		return blinds[0]
+	}
+
+	level := int(elapsed / blindIncrement)
+
+	if level >= len(blinds) {
+		level = len(blinds) - 1
+	}
+
+	return blinds[level]
 }
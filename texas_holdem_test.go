@@ -1,8 +1,10 @@
 package poker_test
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"reflect"
 	"testing"
 	"time"
 
@@ -13,9 +15,9 @@ func TestGame_Start(t *testing.T) {
 
 	t.Run("it schedules alerts on a game for 5 players", func(t *testing.T) {
 		blindAlerter := &poker.SpyBlindAlerter{}
-		game := poker.NewTexasHoldem(blindAlerter, dummyPlayerStore)
+		game := poker.NewTexasHoldem(blindAlerter, dummyPlayerStore, dummyGameStore)
 
-		game.Start(5, ioutil.Discard)
+		mustStartGame(t, game, 5, nil)
 
 		cases := []poker.ScheduledAlert{
 			{At: 0 * time.Second, Amount: 100},
@@ -36,9 +38,9 @@ func TestGame_Start(t *testing.T) {
 
 	t.Run("it schedules alert on a game for 7 players", func(t *testing.T) {
 		blindAlerter := &poker.SpyBlindAlerter{}
-		game := poker.NewTexasHoldem(blindAlerter, dummyPlayerStore)
+		game := poker.NewTexasHoldem(blindAlerter, dummyPlayerStore, dummyGameStore)
 
-		game.Start(7, ioutil.Discard)
+		mustStartGame(t, game, 7, nil)
 
 		cases := []poker.ScheduledAlert{
 			{At: 0 * time.Second, Amount: 100},
@@ -53,13 +55,116 @@ func TestGame_Start(t *testing.T) {
 
 func TestGame_Finish(t *testing.T) {
 	store := &poker.StubPlayerStore{}
-	game := poker.NewTexasHoldem(dummyBlindAlerter, store)
+	game := poker.NewTexasHoldem(dummyBlindAlerter, store, &poker.StubGameStore{})
 
 	winner := "Ruth"
-	game.Finish(winner)
+	err := mustStartGame(t, game, 5, nil).Finish(winner, poker.CLISource)
+	poker.AssertNoError(t, err)
 	poker.AssertPlayerWin(t, store, winner)
 }
 
+func TestGame_FinishRecordsGame(t *testing.T) {
+	games := &poker.StubGameStore{}
+	game := poker.NewTexasHoldem(dummyBlindAlerter, &poker.StubPlayerStore{}, games)
+
+	before := time.Now()
+	err := mustStartGame(t, game, 5, nil).Finish("Ruth", poker.CLISource)
+	poker.AssertNoError(t, err)
+
+	if len(games.Games) != 1 {
+		t.Fatalf("got %d games recorded want %d", len(games.Games), 1)
+	}
+
+	got := games.Games[0]
+
+	if got.Winner != "Ruth" || got.NumberOfPlayers != 5 || got.HighestBlind != 100 {
+		t.Errorf("got game %+v, want Ruth winning a 5 player game at blind 100", got)
+	}
+
+	if got.StartedAt.Before(before) || got.FinishedAt.Before(got.StartedAt) {
+		t.Errorf("got game running from %v to %v, want it to start after %v", got.StartedAt, got.FinishedAt, before)
+	}
+}
+
+func TestGame_FinishRecordsThePlayers(t *testing.T) {
+	games := &poker.StubGameStore{}
+	game := poker.NewTexasHoldem(dummyBlindAlerter, &poker.StubPlayerStore{}, games)
+
+	poker.AssertNoError(t, mustStartGame(t, game, 2, []string{"Chris", "Cleo"}).Finish("cleo", poker.CLISource))
+
+	if got := games.Games[0]; got.NumberOfPlayers != 2 || !reflect.DeepEqual(got.Players, []string{"Chris", "Cleo"}) {
+		t.Errorf("got game %+v want Chris and Cleo's game", got)
+	}
+}
+
+func TestGame_FinishRejectsAWinnerWhoDidNotPlay(t *testing.T) {
+	store := &poker.StubPlayerStore{}
+	games := &poker.StubGameStore{}
+	game := poker.NewTexasHoldem(dummyBlindAlerter, store, games)
+
+	err := mustStartGame(t, game, 2, []string{"Chris", "Cleo"}).Finish("Ruth", poker.CLISource)
+
+	if !errors.Is(err, poker.ErrUnknownWinner) {
+		t.Errorf("got error %v want %v", err, poker.ErrUnknownWinner)
+	}
+
+	if len(store.WinCalls) != 0 || len(games.Games) != 0 {
+		t.Errorf("got wins %v and games %+v recorded, want neither", store.WinCalls, games.Games)
+	}
+}
+
+func TestGame_PlaysGamesAtOnce(t *testing.T) {
+	games := &poker.StubGameStore{}
+	game := poker.NewTexasHoldem(dummyBlindAlerter, &poker.StubPlayerStore{}, games)
+
+	atTable := mustStartGame(t, game, 2, []string{"Chris", "Cleo"})
+	online := mustStartGame(t, game, 3, []string{"Ruth", "Pepper", "Ana"})
+
+	poker.AssertNoError(t, atTable.Finish("Chris", poker.CLISource))
+	poker.AssertNoError(t, online.Finish("Ruth", poker.GameSource("192.0.2.1:1234")))
+
+	if got := games.Games[0]; got.NumberOfPlayers != 2 || !reflect.DeepEqual(got.Players, []string{"Chris", "Cleo"}) {
+		t.Errorf("got game %+v want the game at the table", got)
+	}
+
+	if got := games.Games[1]; got.NumberOfPlayers != 3 || !reflect.DeepEqual(got.Players, []string{"Ruth", "Pepper", "Ana"}) {
+		t.Errorf("got game %+v want the game online", got)
+	}
+}
+
+func TestGame_StartHandsOutTheGameID(t *testing.T) {
+	games := mustMakeGameStore(t)
+	game := poker.NewTexasHoldem(dummyBlindAlerter, &poker.StubPlayerStore{}, games)
+
+	atTable := mustStartGame(t, game, 2, []string{"Chris", "Cleo"})
+	online := mustStartGame(t, game, 2, []string{"Ruth", "Ana"})
+
+	if atTable.ID() == 0 || atTable.ID() == online.ID() {
+		t.Fatalf("got game ids %d and %d want one each", atTable.ID(), online.ID())
+	}
+
+	poker.AssertNoError(t, online.Finish("Ruth", poker.CLISource))
+	poker.AssertNoError(t, atTable.Finish("Chris", poker.CLISource))
+
+	recorded, err := games.GetGame(atTable.ID())
+	poker.AssertNoError(t, err)
+
+	if recorded.Winner != "Chris" {
+		t.Errorf("got game %+v want the game at the table", recorded)
+	}
+}
+
+func TestGame_FinishReturnsStoreErrors(t *testing.T) {
+	store := &poker.StubPlayerStore{Err: errors.New("disk full")}
+	game := poker.NewTexasHoldem(dummyBlindAlerter, store, &poker.StubGameStore{})
+
+	err := mustStartGame(t, game, 5, nil).Finish("Ruth", poker.CLISource)
+
+	if err == nil {
+		t.Error("expected an error but didn't get one")
+	}
+}
+
 func checkSchedulingCases(cases []poker.ScheduledAlert, t *testing.T, blindAlerter *poker.SpyBlindAlerter) {
 	for i, want := range cases {
 		t.Run(fmt.Sprint(want), func(t *testing.T) {
@@ -84,3 +189,12 @@ func assertScheduledAlert(t *testing.T, got, want poker.ScheduledAlert) {
 		t.Errorf("got scheduled time of %v, want %v", got.At, want.At)
 	}
 }
+
+func mustStartGame(t *testing.T, game poker.Game, numberOfPlayers int, players []string) poker.GameInProgress {
+	t.Helper()
+
+	started, err := game.Start(numberOfPlayers, players, ioutil.Discard)
+	poker.AssertNoError(t, err)
+
+	return started
+}
@@ -0,0 +1,103 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrInvalidInterval = errors.New("interval must be above zero")
+
+// Watch checks the store's file every interval and reloads the store when
+// the file has been changed by anything other than the store, such as a fix
+// made by hand or a backup copied over it. A change that doesn't load is
+// passed to onError and the store carries on with the data it had. Calling the
+// returned function stops the watch.
+func (f *FileSystemPlayerStore) Watch(interval time.Duration, onError func(error)) (stop func(), err error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("%w, got %v", ErrInvalidInterval, interval)
+	}
+
+	done := make(chan struct{})
+	var stopped sync.WaitGroup
+	stopped.Add(1)
+
+	go func() {
+		defer stopped.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := f.reloadIfChanged(); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		stopped.Wait()
+	}, nil
+}
+
+// reloadIfChanged reloads the database when its file is no longer the one the
+// store last wrote or loaded.
+func (f *FileSystemPlayerStore) reloadIfChanged() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := f.tape.File.Name()
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return fmt.Errorf("problem checking %s for changes, %v", path, err)
+	}
+
+	if f.seen != nil && sameFileVersion(f.seen, info) {
+		return nil
+	}
+
+	// a bad edit is only reported once, the next change to the file is
+	// checked again
+	f.seen = info
+
+	if err := f.reload(); err != nil {
+		return fmt.Errorf("%s changed but can't be loaded, still serving the last good copy: %v", path, err)
+	}
+
+	return nil
+}
+
+// markSeen remembers the version of the file the store has just written or
+// loaded, so the watch doesn't take it for an outside change.
+func (f *FileSystemPlayerStore) markSeen() {
+	if info, err := os.Stat(f.tape.File.Name()); err == nil {
+		f.seen = info
+	}
+}
+
+// reloadIfStale re-reads the database when another process has written the
+// file since the store last wrote or loaded it. Every write replaces the
+// file, so an unchanged file is the one the store already has in memory.
+func (f *FileSystemPlayerStore) reloadIfStale() error {
+	info, err := os.Stat(f.tape.File.Name())
+
+	if err == nil && f.seen != nil && sameFileVersion(f.seen, info) {
+		return nil
+	}
+
+	return f.reload()
+}
+
+func sameFileVersion(a, b os.FileInfo) bool {
+	return os.SameFile(a, b) && a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
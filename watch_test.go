@@ -0,0 +1,154 @@
+package poker_test
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+const watchInterval = 5 * time.Millisecond
+
+func TestFileSystemPlayerStoreWatch(t *testing.T) {
+	t.Run("reloads a fix made to the file by hand", func(t *testing.T) {
+		store, path := mustMakeWatchedStore(t)
+		stop, err := store.Watch(watchInterval, nil)
+		poker.AssertNoError(t, err)
+		defer stop()
+
+		writeFile(t, path, `{"version":6,"seasons":[{"name":"default","players":[{"Name":"Chris","Wins":20}]}],"aliases":{},"audit":[]}`)
+
+		eventually(t, func() bool {
+			score, _ := store.GetPlayerScore("Chris")
+			return score == 20
+		})
+	})
+
+	t.Run("keeps the fix when it records the next win", func(t *testing.T) {
+		store, path := mustMakeWatchedStore(t)
+		stop, err := store.Watch(watchInterval, nil)
+		poker.AssertNoError(t, err)
+		defer stop()
+
+		writeFile(t, path, `{"version":6,"seasons":[{"name":"default","players":[{"Name":"Chris","Wins":20}]}],"aliases":{},"audit":[]}`)
+
+		eventually(t, func() bool {
+			score, _ := store.GetPlayerScore("Chris")
+			return score == 20
+		})
+
+		poker.MustRecordWin(t, store, "Chris")
+		poker.AssertScore(t, store, "Chris", 21)
+	})
+
+	t.Run("doesn't reload its own writes", func(t *testing.T) {
+		store, _ := mustMakeWatchedStore(t)
+		errs := &watchErrors{}
+		stop, err := store.Watch(watchInterval, errs.add)
+		poker.AssertNoError(t, err)
+		defer stop()
+
+		for i := 0; i < 5; i++ {
+			poker.MustRecordWin(t, store, "Chris")
+		}
+		time.Sleep(5 * watchInterval)
+
+		poker.AssertScore(t, store, "Chris", 5)
+		if got := errs.count(); got != 0 {
+			t.Errorf("got %d errors from the watch want none", got)
+		}
+	})
+
+	t.Run("rejects an interval that isn't above zero", func(t *testing.T) {
+		store, _ := mustMakeWatchedStore(t)
+
+		_, err := store.Watch(-time.Second, nil)
+		assertErrorIs(t, err, poker.ErrInvalidInterval)
+	})
+
+	t.Run("reports a bad edit once and keeps serving the last good copy", func(t *testing.T) {
+		store, path := mustMakeWatchedStore(t)
+		poker.MustRecordWin(t, store, "Chris")
+
+		errs := &watchErrors{}
+		stop, err := store.Watch(watchInterval, errs.add)
+		poker.AssertNoError(t, err)
+		defer stop()
+
+		writeFile(t, path, `{"version":6,"seasons":[{"name":"default","players":[{"Name":"Chris"`)
+
+		eventually(t, func() bool { return errs.count() > 0 })
+		time.Sleep(5 * watchInterval)
+
+		if got := errs.count(); got != 1 {
+			t.Errorf("got %d errors from the watch want %d", got, 1)
+		}
+		poker.AssertScore(t, store, "Chris", 1)
+	})
+
+	t.Run("keeps the last good copy as the backup over a bad edit", func(t *testing.T) {
+		store, path := mustMakeWatchedStore(t)
+		poker.MustRecordWin(t, store, "Chris")
+		poker.MustRecordWin(t, store, "Chris")
+
+		errs := &watchErrors{}
+		stop, err := store.Watch(watchInterval, errs.add)
+		poker.AssertNoError(t, err)
+		defer stop()
+
+		writeFile(t, path, `{"version":6,"seasons":[{"name":"default","players":[{"Name":"Chris"`)
+		eventually(t, func() bool { return errs.count() > 0 })
+
+		poker.MustRecordWin(t, store, "Chris")
+
+		backup, closeBackup, err := poker.FileSystemPlayerStoreFromFile(path + ".bak")
+		poker.AssertNoError(t, err)
+		defer closeBackup()
+
+		poker.AssertScore(t, backup, "Chris", 1)
+	})
+}
+
+func mustMakeWatchedStore(t *testing.T) (*poker.FileSystemPlayerStore, string) {
+	t.Helper()
+
+	store, path := mustMakeSharedStore(t)
+
+	// make sure the hand edits the tests make change the file's modification
+	// time even where it only has a coarse resolution
+	past := time.Now().Add(-time.Hour)
+	poker.AssertNoError(t, os.Chtimes(path, past, past))
+
+	return store, path
+}
+
+type watchErrors struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (w *watchErrors) add(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.errs = append(w.errs, err)
+}
+
+func (w *watchErrors) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.errs)
+}
+
+func eventually(t testing.TB, done func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(watchInterval) {
+		if done() {
+			return
+		}
+	}
+
+	t.Fatal("gave up waiting")
+}
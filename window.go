@@ -0,0 +1,204 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidWindow = errors.New("invalid window")
+
+// WindowStore is implemented by player stores that keep the time of every
+// win, so standings can be worked out from just the wins in a window of time.
+type WindowStore interface {
+	GetWindowLeague(window Window) (League, error)
+}
+
+// Window is a range of time wins are counted in. From is inclusive and To is
+// exclusive, a zero From or To leaves that end of the window open.
+type Window struct {
+	From time.Time
+	To   time.Time
+}
+
+const (
+	WeekWindow  = "week"
+	MonthWindow = "month"
+)
+
+// ParseWindow returns the named window as it stands at now. The names are
+// week and month, which start on the Monday and the first of the month in
+// UTC, and rolling-Nd, the last N days.
+func ParseWindow(name string, now time.Time) (Window, error) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch {
+	case name == WeekWindow:
+		daysSinceMonday := (int(today.Weekday()) + 6) % 7
+		return Window{From: today.AddDate(0, 0, -daysSinceMonday)}, nil
+	case name == MonthWindow:
+		return Window{From: today.AddDate(0, 0, 1-today.Day())}, nil
+	case strings.HasPrefix(name, "rolling-") && strings.HasSuffix(name, "d"):
+		days, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "rolling-"), "d"))
+
+		if err != nil || days < 1 {
+			return Window{}, fmt.Errorf("%w: %q needs a number of days above zero", ErrInvalidWindow, name)
+		}
+
+		return Window{From: now.AddDate(0, 0, -days)}, nil
+	default:
+		return Window{}, fmt.Errorf("%w: %q, want %s, %s or rolling-Nd", ErrInvalidWindow, name, WeekWindow, MonthWindow)
+	}
+}
+
+// NewWindow returns the window between from and to, either of which may be
+// empty. Each is a date, 2006-01-02, or an RFC 3339 time.
+func NewWindow(from, to string) (Window, error) {
+	var window Window
+	var err error
+
+	if window.From, err = parseWindowTime(from); err != nil {
+		return Window{}, err
+	}
+
+	if window.To, err = parseWindowTime(to); err != nil {
+		return Window{}, err
+	}
+
+	if !window.From.IsZero() && !window.To.IsZero() && !window.From.Before(window.To) {
+		return Window{}, fmt.Errorf("%w: %s is not before %s", ErrInvalidWindow, from, to)
+	}
+
+	return window, nil
+}
+
+func parseWindowTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if at, err := time.Parse("2006-01-02", value); err == nil {
+		return at, nil
+	}
+
+	at, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q is not a date or an RFC 3339 time", ErrInvalidWindow, value)
+	}
+
+	return at.UTC(), nil
+}
+
+func (w Window) Contains(at time.Time) bool {
+	return !at.Before(w.From) && (w.To.IsZero() || at.Before(w.To))
+}
+
+// GetWindowLeague returns the standings from the wins recorded in window,
+// across every season. Wins are taken from the audit log, so only the wins
+// still in the log count: once it holds KeepAudit entries the oldest are
+// dropped, and the wins they logged drop out of every window. A win counts for the player who
+// now holds it, after any renames and merges, unless the win was undone or the
+// player was deleted since from the season it was won in. Scores set by hand
+// aren't wins and don't count.
+func (f *FileSystemPlayerStore) GetWindowLeague(window Window) (League, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.db.windowLeague(window), nil
+}
+
+// windowTally is the wins in a window of whoever holds a name now, by season.
+type windowTally struct {
+	name string
+	wins map[string]int
+}
+
+func (db *playerDB) windowLeague(window Window) League {
+	// tallies are kept by the player key of their holder, renames and merges
+	// move a tally to the new key rather than touching every win
+	holders := map[string]*windowTally{}
+	renames := map[int]bool{}
+
+	move := func(from, to string) {
+		moved := holders[playerKey(from)]
+
+		if moved == nil {
+			return
+		}
+
+		delete(holders, playerKey(from))
+
+		if into := holders[playerKey(to)]; into != nil {
+			for season, wins := range moved.wins {
+				into.wins[season] += wins
+			}
+			return
+		}
+
+		moved.name = to
+		holders[playerKey(to)] = moved
+	}
+
+	for _, record := range db.Audit {
+		switch record.Action {
+		case winAction:
+			if record.UndoneBy != 0 || !window.Contains(record.At) {
+				continue
+			}
+
+			tally := holders[playerKey(record.Player)]
+
+			if tally == nil {
+				tally = &windowTally{name: record.Player, wins: map[string]int{}}
+				holders[playerKey(record.Player)] = tally
+			}
+
+			tally.wins[record.Season]++
+		case renameAction, mergeAction:
+			renames[record.ID] = record.Action == renameAction
+			move(record.Player, record.To)
+		case deleteAction:
+			// a player is deleted from one season only
+			if tally := holders[playerKey(record.Player)]; tally != nil && record.UndoneBy == 0 {
+				delete(tally.wins, record.Season)
+			}
+		case undoAction:
+			if renames[record.Undoes] {
+				move(record.Player, record.To)
+			}
+		}
+	}
+
+	league := League{}
+
+	for _, tally := range holders {
+		wins := 0
+		for _, seasonWins := range tally.wins {
+			wins += seasonWins
+		}
+
+		if wins > 0 {
+			league = append(league, Player{tally.name, wins})
+		}
+	}
+
+	league.sortByWins()
+	return league
+}
+
+// gamesIn returns the games that finished in window.
+func gamesIn(window Window, games []GameRecord) []GameRecord {
+	var in []GameRecord
+
+	for _, game := range games {
+		if window.Contains(game.FinishedAt) {
+			in = append(in, game)
+		}
+	}
+
+	return in
+}
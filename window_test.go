@@ -0,0 +1,204 @@
+package poker_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+// windowDB has a win for Chris in the last week of January, one for Cleo and
+// two for Chris in February, and one for Ruth in February that was undone.
+const windowDB = `{"version":6,
+  "seasons":[{"name":"default","players":[{"Name":"Chris","Wins":3},{"Name":"Cleo","Wins":1}]}],
+  "aliases":{},
+  "audit":[
+    {"id":1,"at":"2026-01-28T20:00:00Z","action":"win","player":"Chris","season":"default","before":0,"after":1},
+    {"id":2,"at":"2026-02-02T20:00:00Z","action":"win","player":"Cleo","season":"default","before":0,"after":1},
+    {"id":3,"at":"2026-02-09T20:00:00Z","action":"win","player":"Chris","season":"default","before":1,"after":2},
+    {"id":4,"at":"2026-02-10T20:00:00Z","action":"win","player":"Ruth","season":"default","before":0,"after":1,"undoneBy":5},
+    {"id":5,"at":"2026-02-10T20:05:00Z","action":"undo","player":"Ruth","season":"default","before":1,"after":0,"undoes":4},
+    {"id":6,"at":"2026-02-11T20:00:00Z","action":"win","player":"Chris","season":"default","before":2,"after":3}]}`
+
+func TestParseWindow(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2026, time.February, 11, 15, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		from time.Time
+	}{
+		{"week", time.Date(2026, time.February, 9, 0, 0, 0, 0, time.UTC)},
+		{"month", time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"rolling-90d", time.Date(2025, time.November, 13, 15, 30, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			window, err := poker.ParseWindow(c.name, now)
+			poker.AssertNoError(t, err)
+
+			if want := (poker.Window{From: c.from}); window != want {
+				t.Errorf("got window %+v want %+v", window, want)
+			}
+		})
+	}
+
+	for _, name := range []string{"fortnight", "rolling-0d", "rolling-ad"} {
+		t.Run("rejects "+name, func(t *testing.T) {
+			if _, err := poker.ParseWindow(name, now); !errors.Is(err, poker.ErrInvalidWindow) {
+				t.Errorf("got error %v want %v", err, poker.ErrInvalidWindow)
+			}
+		})
+	}
+}
+
+func TestFileSystemPlayerStoreWindowLeague(t *testing.T) {
+	february, err := poker.NewWindow("2026-02-01", "2026-03-01")
+	poker.AssertNoError(t, err)
+
+	t.Run("counts only the wins in the window", func(t *testing.T) {
+		store := mustMakeWindowStore(t)
+
+		assertWindowLeague(t, store, february, poker.League{{"Chris", 2}, {"Cleo", 1}})
+		assertWindowLeague(t, store, poker.Window{}, poker.League{{"Chris", 3}, {"Cleo", 1}})
+	})
+
+	t.Run("counts wins for the player who holds them now", func(t *testing.T) {
+		store := mustMakeWindowStore(t)
+
+		poker.AssertNoError(t, store.RenamePlayer("Chris", "Christopher", "full name"))
+		poker.AssertNoError(t, store.MergePlayers("Cleo", "Christopher"))
+
+		assertWindowLeague(t, store, february, poker.League{{"Christopher", 3}})
+	})
+
+	t.Run("gives the wins back when a rename is undone", func(t *testing.T) {
+		store := mustMakeWindowStore(t)
+
+		poker.AssertNoError(t, store.RenamePlayer("Chris", "Cleo Two", "typo"))
+		poker.AssertNoError(t, store.Undo(lastAuditEntry(t, store).ID))
+
+		assertWindowLeague(t, store, february, poker.League{{"Chris", 2}, {"Cleo", 1}})
+	})
+
+	t.Run("drops the wins of a deleted player", func(t *testing.T) {
+		store := mustMakeWindowStore(t)
+
+		poker.AssertNoError(t, store.DeletePlayer("Cleo", "left the club"))
+
+		assertWindowLeague(t, store, february, poker.League{{"Chris", 2}})
+	})
+
+	t.Run("keeps the wins from other seasons of a deleted player", func(t *testing.T) {
+		store := mustMakeWindowStore(t)
+
+		poker.AssertNoError(t, store.StartSeason("2026-Q4"))
+		poker.MustRecordWin(t, store, "Cleo")
+		poker.AssertNoError(t, store.DeletePlayer("Cleo", "entered by mistake"))
+
+		assertWindowLeague(t, store, poker.Window{}, poker.League{{"Chris", 3}, {"Cleo", 1}})
+	})
+
+	t.Run("counts new wins in an open window", func(t *testing.T) {
+		store := mustMakeWindowStore(t)
+		poker.MustRecordWin(t, store, "Ruth")
+
+		window, err := poker.ParseWindow("rolling-1d", time.Now())
+		poker.AssertNoError(t, err)
+
+		assertWindowLeague(t, store, window, poker.League{{"Ruth", 1}})
+	})
+}
+
+func TestWindowLeagueOverHTTP(t *testing.T) {
+	store := mustMakeWindowStore(t)
+	server, _ := poker.NewPlayerServer(store, &poker.StubGameStore{}, dummyGame)
+
+	t.Run("returns the league between from and to", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetLeagueQueryRequest("from=2026-02-01&to=2026-02-10"))
+
+		var stats []poker.PlayerStats
+		decodeJSON(t, response, &stats)
+
+		if len(stats) != 2 || stats[0].Name != "Chris" || stats[0].Wins != 1 || stats[1].Name != "Cleo" {
+			t.Errorf("got league %+v want Chris and Cleo on a win each", stats)
+		}
+	})
+
+	for _, query := range []string{"window=fortnight", "from=yesterday", "from=2026-03-01&to=2026-02-01", "window=week&from=2026-02-01"} {
+		t.Run("returns 400 for "+query, func(t *testing.T) {
+			response := httptest.NewRecorder()
+			server.ServeHTTP(response, poker.NewGetLeagueQueryRequest(query))
+
+			assertStatus(t, response, http.StatusBadRequest)
+		})
+	}
+
+	t.Run("doesn't read the whole league for a window", func(t *testing.T) {
+		store := &windowOnlyStore{window: poker.League{{"Chris", 1}}}
+		server, _ := poker.NewPlayerServer(store, &poker.StubGameStore{}, dummyGame)
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetLeagueQueryRequest("window=month"))
+
+		assertStatus(t, response, http.StatusOK)
+		poker.AssertLeague(t, poker.GetLeagueFromResponse(t, response.Body), store.window)
+
+		if store.leagueReads != 0 {
+			t.Errorf("read the whole league %d times, want none", store.leagueReads)
+		}
+	})
+
+	t.Run("returns 501 when the store keeps no win times", func(t *testing.T) {
+		server, _ := poker.NewPlayerServer(&poker.StubPlayerStore{}, &poker.StubGameStore{}, dummyGame)
+		response := httptest.NewRecorder()
+		server.ServeHTTP(response, poker.NewGetLeagueQueryRequest("window=month"))
+
+		assertStatus(t, response, http.StatusNotImplemented)
+	})
+}
+
+// windowOnlyStore serves window leagues and counts the reads of the whole
+// league, which fail.
+type windowOnlyStore struct {
+	poker.StubPlayerStore
+	window      poker.League
+	leagueReads int
+}
+
+func (s *windowOnlyStore) GetLeague() (poker.League, error) {
+	s.leagueReads++
+	return nil, errors.New("the whole league can't be read")
+}
+
+func (s *windowOnlyStore) GetWindowLeague(window poker.Window) (poker.League, error) {
+	return s.window, nil
+}
+
+func mustMakeWindowStore(t *testing.T) *poker.FileSystemPlayerStore {
+	t.Helper()
+
+	database, cleanDatabase := createTempFile(t, windowDB)
+	t.Cleanup(cleanDatabase)
+
+	store, err := poker.NewFileSystemPlayerStore(database)
+	poker.AssertNoError(t, err)
+
+	return store
+}
+
+func assertWindowLeague(t *testing.T, store poker.WindowStore, window poker.Window, want poker.League) {
+	t.Helper()
+
+	got, err := store.GetWindowLeague(window)
+	poker.AssertNoError(t, err)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got league %v want %v", got, want)
+	}
+}
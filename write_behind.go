@@ -0,0 +1,132 @@
+package poker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultMaxPending = 100
+
+// pendingWin is a win recorded in write-behind mode, kept so it can be
+// applied again on top of the file when the store re-reads it.
+type pendingWin struct {
+	name   string
+	at     time.Time
+	source Source
+}
+
+// WriteBehind turns on write-behind mode, in which RecordWin updates the
+// league in memory and returns straight away, and wins are saved in batches:
+// every interval, or as soon as maxPending are waiting, whichever comes first.
+// A maxPending of zero is taken as 100. Every other change is saved as it's
+// made, along with any waiting wins.
+//
+// A win is only durable once its batch is saved, so a crash loses at most the
+// wins of the last interval, and never more than maxPending of them. Calling
+// the returned function saves the waiting wins and turns write-behind mode
+// off, call it before shutting down. A batch that fails to save is passed to
+// onError and tried again with the next one. While maxPending wins are still
+// waiting RecordWin saves them itself, along with its own win, and returns
+// the error when they can't be saved.
+func (f *FileSystemPlayerStore) WriteBehind(interval time.Duration, maxPending int, onError func(error)) (stop func() error, err error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("%w, got %v", ErrInvalidInterval, interval)
+	}
+
+	if maxPending <= 0 {
+		maxPending = defaultMaxPending
+	}
+
+	f.mu.Lock()
+	f.flush = make(chan struct{}, 1)
+	f.maxPending = maxPending
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	var stopped sync.WaitGroup
+	stopped.Add(1)
+
+	go func() {
+		defer stopped.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			case <-f.flush:
+			}
+
+			if err := f.Flush(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() error {
+		once.Do(func() { close(done) })
+		stopped.Wait()
+
+		f.mu.Lock()
+		f.flush = nil
+		f.mu.Unlock()
+
+		return f.Flush()
+	}, nil
+}
+
+// Flush saves the wins waiting to be written behind.
+func (f *FileSystemPlayerStore) Flush() error {
+	f.mu.RLock()
+	pending := len(f.pending)
+	f.mu.RUnlock()
+
+	if pending == 0 {
+		return nil
+	}
+
+	if err := f.update(func(db *playerDB) error { return nil }); err != nil {
+		return fmt.Errorf("problem saving %d wins written behind, %v", pending, err)
+	}
+
+	return nil
+}
+
+// recordWinBehind records the win in memory when the store is in write-behind
+// mode and fewer than maxPending wins are waiting, and reports whether it did.
+func (f *FileSystemPlayerStore) recordWinBehind(name string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.flush == nil || len(f.pending) >= f.maxPending {
+		return false
+	}
+
+	win := pendingWin{name: name, at: time.Now().UTC(), source: f.source}
+	f.db.applyWin(win)
+	f.pending = append(f.pending, win)
+	f.version++
+
+	if len(f.pending) >= f.maxPending {
+		select {
+		case f.flush <- struct{}{}:
+		default:
+		}
+	}
+
+	return true
+}
+
+func (db *playerDB) applyWin(win pendingWin) {
+	db.recordWin(win.name)
+
+	logged := &db.Audit[len(db.Audit)-1]
+	logged.At = win.at
+	logged.Source = win.source.Kind
+	logged.Remote = win.source.Remote
+}
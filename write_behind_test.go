@@ -0,0 +1,216 @@
+package poker_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	poker "github.com/ljones140/golang-player-webserver"
+)
+
+func TestFileSystemPlayerStoreWriteBehind(t *testing.T) {
+	t.Run("records wins in memory and saves them when stopped", func(t *testing.T) {
+		store, path := mustMakeSharedStore(t)
+		stop, err := store.WriteBehind(time.Hour, 0, nil)
+		poker.AssertNoError(t, err)
+
+		poker.MustRecordWin(t, store, "Chris")
+		poker.MustRecordWin(t, store, "Chris")
+
+		poker.AssertScore(t, store, "Chris", 2)
+		assertScoreOnDisk(t, path, "Chris", 0)
+
+		poker.AssertNoError(t, stop())
+		assertScoreOnDisk(t, path, "Chris", 2)
+	})
+
+	t.Run("saves a batch once enough wins are waiting", func(t *testing.T) {
+		store, path := mustMakeSharedStore(t)
+		stop, err := store.WriteBehind(time.Hour, 3, nil)
+		poker.AssertNoError(t, err)
+		defer stop()
+
+		for i := 0; i < 3; i++ {
+			poker.MustRecordWin(t, store, "Chris")
+		}
+
+		eventually(t, func() bool { return scoreOnDisk(t, path, "Chris") == 3 })
+	})
+
+	t.Run("saves a batch every interval", func(t *testing.T) {
+		store, path := mustMakeSharedStore(t)
+		stop, err := store.WriteBehind(watchInterval, 0, nil)
+		poker.AssertNoError(t, err)
+		defer stop()
+
+		poker.MustRecordWin(t, store, "Chris")
+
+		eventually(t, func() bool { return scoreOnDisk(t, path, "Chris") == 1 })
+	})
+
+	t.Run("saves waiting wins along with any other change", func(t *testing.T) {
+		store, path := mustMakeSharedStore(t)
+		stop, err := store.WriteBehind(time.Hour, 0, nil)
+		poker.AssertNoError(t, err)
+		defer stop()
+
+		poker.MustRecordWin(t, store, "Chris")
+		poker.AssertNoError(t, store.RenamePlayer("Chris", "Christopher", "full name"))
+
+		assertScoreOnDisk(t, path, "Christopher", 1)
+	})
+
+	t.Run("keeps changes made by other processes in the meantime", func(t *testing.T) {
+		store, path := mustMakeSharedStore(t)
+		stop, err := store.WriteBehind(time.Hour, 0, nil)
+		poker.AssertNoError(t, err)
+
+		poker.MustRecordWin(t, store, "Chris")
+
+		other, closeOther, err := poker.FileSystemPlayerStoreFromFile(path)
+		poker.AssertNoError(t, err)
+		poker.MustRecordWin(t, other, "Cleo")
+		closeOther()
+
+		poker.AssertNoError(t, stop())
+
+		assertScoreOnDisk(t, path, "Chris", 1)
+		assertScoreOnDisk(t, path, "Cleo", 1)
+	})
+
+	t.Run("saves the wins itself while maxPending are still waiting", func(t *testing.T) {
+		store, path := mustMakeSharedStore(t)
+		stop, err := store.WriteBehind(time.Hour, 2, nil)
+		poker.AssertNoError(t, err)
+		defer stop()
+
+		// a directory in place of the file fails every save
+		poker.AssertNoError(t, os.Remove(path))
+		poker.AssertNoError(t, os.Mkdir(path, 0755))
+
+		poker.MustRecordWin(t, store, "Chris")
+		poker.MustRecordWin(t, store, "Chris")
+
+		if err := store.RecordWin("Chris"); err == nil {
+			t.Error("expected the win to fail with the waiting wins that can't be saved")
+		}
+	})
+
+	t.Run("rejects an interval that isn't above zero", func(t *testing.T) {
+		store, _ := mustMakeSharedStore(t)
+
+		_, err := store.WriteBehind(0, 10, nil)
+		assertErrorIs(t, err, poker.ErrInvalidInterval)
+	})
+
+	t.Run("logs each win with when and where it was recorded", func(t *testing.T) {
+		store, _ := mustMakeSharedStore(t)
+		stop, err := store.WriteBehind(time.Hour, 0, nil)
+		poker.AssertNoError(t, err)
+
+		before := time.Now()
+		poker.MustRecordWin(t, store.From(poker.HTTPSource("192.0.2.1:1234")), "Chris")
+		time.Sleep(10 * time.Millisecond)
+
+		poker.AssertNoError(t, stop())
+
+		entry := lastAuditEntry(t, store)
+		if entry.Source != poker.HTTPSource("192.0.2.1:1234") || entry.At.Before(before) || entry.At.After(before.Add(5*time.Millisecond)) {
+			t.Errorf("got audit entry %+v want the win as it was recorded", entry)
+		}
+	})
+}
+
+// BenchmarkRecordWin compares saving every win as it's recorded with writing
+// wins behind in batches, in a league of a thousand players.
+func BenchmarkRecordWin(b *testing.B) {
+	league := make(poker.League, 1000)
+	for i := range league {
+		league[i] = poker.Player{Name: fmt.Sprintf("Player %04d", i), Wins: 1 + i%50}
+	}
+
+	data, err := json.Marshal(league)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	open := func(b *testing.B) *poker.FileSystemPlayerStore {
+		database, cleanDatabase := createTempFile(b, string(data))
+		b.Cleanup(cleanDatabase)
+
+		store, closeStore, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(closeStore)
+
+		return store
+	}
+
+	b.Run("synchronous", func(b *testing.B) {
+		store := open(b)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			poker.MustRecordWin(b, store, league[i%len(league)].Name)
+		}
+	})
+
+	b.Run("write-behind", func(b *testing.B) {
+		store := open(b)
+		stop, err := store.WriteBehind(100*time.Millisecond, 100, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			poker.MustRecordWin(b, store, league[i%len(league)].Name)
+		}
+
+		if err := stop(); err != nil {
+			b.Fatal(err)
+		}
+	})
+}
+
+func mustMakeSharedStore(t *testing.T) (*poker.FileSystemPlayerStore, string) {
+	t.Helper()
+
+	database, cleanDatabase := createTempFile(t, "")
+	t.Cleanup(cleanDatabase)
+
+	store, closeStore, err := poker.FileSystemPlayerStoreFromFile(database.Name())
+	poker.AssertNoError(t, err)
+	t.Cleanup(closeStore)
+
+	return store, database.Name()
+}
+
+// scoreOnDisk opens the database afresh to read a player's score as saved.
+func scoreOnDisk(t testing.TB, path, name string) int {
+	t.Helper()
+
+	store, closeStore, err := poker.FileSystemPlayerStoreFromFile(path)
+	if err != nil {
+		t.Fatalf("could not open %s, %v", path, err)
+	}
+	defer closeStore()
+
+	score, err := store.GetPlayerScore(name)
+	if err != nil {
+		t.Fatalf("could not read the score of %s, %v", name, err)
+	}
+
+	return score
+}
+
+func assertScoreOnDisk(t testing.TB, path, name string, want int) {
+	t.Helper()
+
+	if got := scoreOnDisk(t, path, name); got != want {
+		t.Errorf("got score %d for %s on disk, want %d", got, name, want)
+	}
+}